@@ -171,20 +171,29 @@ func gatherWithTimeout(
 }
 
 // Test verifies that we can 'Gather' from all inputs with their configured
-// Config struct
+// Config struct. If processors are configured, the gathered metrics are also
+// passed through them and the results are printed.
 func (a *Agent) Test() error {
-	shutdown := make(chan struct{})
-	defer close(shutdown)
 	metricC := make(chan telegraf.Metric)
+	done := make(chan struct{})
+	defer func() {
+		close(metricC)
+		<-done
+	}()
 
-	// dummy receiver for the point channel
+	// receiver for the point channel, printing the processed metrics
 	go func() {
-		for {
-			select {
-			case <-metricC:
-				// do nothing
-			case <-shutdown:
-				return
+		defer close(done)
+		for metric := range metricC {
+			if len(a.Config.Processors) == 0 {
+				continue
+			}
+			mS := []telegraf.Metric{metric}
+			for _, processor := range a.Config.Processors {
+				mS = processor.Apply(mS...)
+			}
+			for _, m := range mS {
+				fmt.Println("+ " + m.String())
 			}
 		}
 	}()
@@ -1,9 +1,15 @@
 package agent
 
 import (
+	"bytes"
+	"io"
+	"os"
 	"testing"
+	"time"
 
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal/config"
+	"github.com/influxdata/telegraf/internal/models"
 
 	// needing to load the plugins
 	_ "github.com/influxdata/telegraf/plugins/inputs/all"
@@ -11,6 +17,7 @@ import (
 	_ "github.com/influxdata/telegraf/plugins/outputs/all"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestAgent_OmitHostname(t *testing.T) {
@@ -109,3 +116,67 @@ func TestAgent_LoadOutput(t *testing.T) {
 	a, _ = NewAgent(c)
 	assert.Equal(t, 3, len(a.Config.Outputs))
 }
+
+type testInput struct{}
+
+func (i *testInput) SampleConfig() string { return "" }
+func (i *testInput) Description() string  { return "" }
+func (i *testInput) Gather(acc telegraf.Accumulator) error {
+	acc.AddFields("test",
+		map[string]interface{}{"value": int64(1)},
+		map[string]string{},
+		time.Unix(0, 0))
+	return nil
+}
+
+type testProcessor struct{}
+
+func (p *testProcessor) SampleConfig() string { return "" }
+func (p *testProcessor) Description() string  { return "" }
+func (p *testProcessor) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	var out []telegraf.Metric
+	for _, m := range in {
+		p, _ := telegraf.NewMetric(m.Name()+"_processed", m.Tags(), m.Fields(), m.Time())
+		out = append(out, p)
+	}
+	return out
+}
+
+func TestAgent_TestPrintsProcessedMetrics(t *testing.T) {
+	c := config.NewConfig()
+	c.Agent.OmitHostname = true
+	c.Inputs = append(c.Inputs, &models.RunningInput{
+		Input:  &testInput{},
+		Config: &models.InputConfig{Name: "test"},
+	})
+	c.Processors = append(c.Processors, &models.RunningProcessor{
+		Name:      "test",
+		Processor: &testProcessor{},
+		Config:    &models.ProcessorConfig{Name: "test"},
+	})
+	a, err := NewAgent(c)
+	require.NoError(t, err)
+
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	var out bytes.Buffer
+	done := make(chan struct{})
+	go func() {
+		io.Copy(&out, r)
+		close(done)
+	}()
+
+	err = a.Test()
+	os.Stdout = stdout
+	w.Close()
+	<-done
+	require.NoError(t, err)
+
+	assert.Equal(t, "* Plugin: inputs.test, Collection 1\n"+
+		"> test value=1i 0\n"+
+		"+ test_processed value=1i 0\n", out.String())
+}
@@ -10,7 +10,9 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log"
+	"math"
 	"math/big"
+	"net/http"
 	"os"
 	"os/exec"
 	"strconv"
@@ -145,6 +147,29 @@ func GetTLSConfig(
 	return t, nil
 }
 
+// NewHTTPClient returns a client for the outputs writing over HTTP, using
+// the proxy of the environment and the given TLS config, which may be nil.
+func NewHTTPClient(timeout time.Duration, tlsCfg *tls.Config) *http.Client {
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsCfg,
+		},
+		Timeout: timeout,
+	}
+}
+
+// RetriableStatus reports whether a write failing with the HTTP status may
+// succeed later: server errors, 408 Request Timeout and 429 Too Many
+// Requests. Outputs return these failures as errors so that the metrics are
+// written again, and drop the metrics of the other failures, which would
+// fail the same way forever.
+func RetriableStatus(status int) bool {
+	return status >= 500 ||
+		status == http.StatusRequestTimeout ||
+		status == http.StatusTooManyRequests
+}
+
 // SnakeCase converts the given string to snake case following the Golang format:
 // acronyms are converted to lower-case and preceded by an underscore.
 func SnakeCase(in string) string {
@@ -230,3 +255,70 @@ func RandomSleep(max time.Duration, shutdown chan struct{}) {
 		return
 	}
 }
+
+// ParseTimestamp parses a timestamp with the given format, which is either
+// one of "unix", "unix_ms", "unix_us" and "unix_ns" for numeric epochs, or a
+// layout as understood by time.Parse. Without a format, numbers are read as
+// unix seconds and strings as RFC3339. v can be a string, float64 or int64.
+func ParseTimestamp(v interface{}, format string) (time.Time, error) {
+	var f float64
+	switch v := v.(type) {
+	case float64:
+		f = v
+	case int64:
+		f = float64(v)
+		switch format {
+		case "", "unix":
+			return time.Unix(v, 0).UTC(), nil
+		case "unix_ms", "unix_us", "unix_ns":
+			return epochInt(v, format), nil
+		}
+	case string:
+		switch format {
+		case "unix", "unix_ms", "unix_us", "unix_ns":
+			if i, err := strconv.ParseInt(v, 10, 64); err == nil {
+				return ParseTimestamp(i, format)
+			}
+			var err error
+			if f, err = strconv.ParseFloat(v, 64); err != nil {
+				return time.Time{}, fmt.Errorf("invalid %s timestamp %q", format, v)
+			}
+		case "":
+			return time.Parse(time.RFC3339Nano, v)
+		default:
+			return time.Parse(format, v)
+		}
+	default:
+		return time.Time{}, fmt.Errorf("invalid timestamp %v", v)
+	}
+
+	switch format {
+	case "", "unix":
+		return epoch(f, int64(time.Second)), nil
+	case "unix_ms":
+		return epoch(f, int64(time.Millisecond)), nil
+	case "unix_us":
+		return epoch(f, int64(time.Microsecond)), nil
+	case "unix_ns":
+		return epoch(f, 1), nil
+	}
+	return time.Time{}, fmt.Errorf("numeric timestamp %v does not match layout %q", v, format)
+}
+
+func epochInt(v int64, format string) time.Time {
+	switch format {
+	case "unix_ms":
+		return time.Unix(0, v*int64(time.Millisecond)).UTC()
+	case "unix_us":
+		return time.Unix(0, v*int64(time.Microsecond)).UTC()
+	default:
+		return time.Unix(0, v).UTC()
+	}
+}
+
+// epoch returns the time of a unix epoch counted in the given unit of
+// nanoseconds. The integer part is converted separately to keep precision.
+func epoch(f float64, unit int64) time.Time {
+	i, frac := math.Modf(f)
+	return time.Unix(0, int64(i)*unit+int64(frac*float64(unit))).UTC()
+}
@@ -1,6 +1,7 @@
 package internal
 
 import (
+	"net/http"
 	"os/exec"
 	"testing"
 	"time"
@@ -131,3 +132,22 @@ func TestRandomSleep(t *testing.T) {
 	elapsed = time.Since(s)
 	assert.True(t, elapsed < time.Millisecond*150)
 }
+
+func TestRetriableStatus(t *testing.T) {
+	tests := []struct {
+		status    int
+		retriable bool
+	}{
+		{http.StatusBadRequest, false},
+		{http.StatusUnauthorized, false},
+		{http.StatusNotFound, false},
+		{http.StatusRequestEntityTooLarge, false},
+		{http.StatusRequestTimeout, true},
+		{http.StatusTooManyRequests, true},
+		{http.StatusInternalServerError, true},
+		{http.StatusServiceUnavailable, true},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.retriable, RetriableStatus(tt.status), "status %d", tt.status)
+	}
+}
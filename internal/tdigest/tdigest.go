@@ -0,0 +1,220 @@
+// Package tdigest implements the merging t-digest, a mergeable sketch for
+// estimating quantiles of a stream of values with bounded memory. Estimates
+// are most accurate near the extreme quantiles.
+//
+// See https://github.com/tdunning/t-digest/blob/master/docs/t-digest-paper/histo.pdf
+package tdigest
+
+import (
+	"math"
+	"sort"
+)
+
+// DefaultCompression is a good trade off between size and accuracy, keeping
+// about 100 centroids.
+const DefaultCompression = 100
+
+type centroid struct {
+	mean   float64
+	weight float64
+}
+
+type centroids []centroid
+
+func (c centroids) Len() int           { return len(c) }
+func (c centroids) Less(i, j int) bool { return c[i].mean < c[j].mean }
+func (c centroids) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
+
+// TDigest holds the summary of the values added to it. It is not safe for
+// concurrent use.
+type TDigest struct {
+	compression float64
+	bufferSize  int
+
+	// merged holds the compressed centroids, sorted by mean.
+	merged centroids
+	// unmerged holds values which have been added since the last compression.
+	unmerged centroids
+
+	count float64
+	min   float64
+	max   float64
+}
+
+// New returns an empty t-digest. A higher compression results in more
+// centroids and more accurate estimates. The number of centroids is about
+// the compression.
+func New(compression float64) *TDigest {
+	if compression <= 0 {
+		compression = DefaultCompression
+	}
+	t := &TDigest{
+		compression: compression,
+		bufferSize:  int(math.Ceil(compression)) * 5,
+	}
+	t.Reset()
+	return t
+}
+
+// Add adds a value to the digest.
+func (t *TDigest) Add(v float64) {
+	t.AddWeighted(v, 1)
+}
+
+// AddWeighted adds a value occurring weight times to the digest.
+func (t *TDigest) AddWeighted(v float64, weight float64) {
+	if math.IsNaN(v) || weight <= 0 {
+		return
+	}
+	if t.count == 0 || v < t.min {
+		t.min = v
+	}
+	if t.count == 0 || v > t.max {
+		t.max = v
+	}
+	t.count += weight
+	t.unmerged = append(t.unmerged, centroid{mean: v, weight: weight})
+	if len(t.unmerged) >= t.bufferSize {
+		t.compress()
+	}
+}
+
+// Merge adds all values summarized by other to the digest.
+func (t *TDigest) Merge(other *TDigest) {
+	if other.count == 0 {
+		return
+	}
+	if t.count == 0 || other.min < t.min {
+		t.min = other.min
+	}
+	if t.count == 0 || other.max > t.max {
+		t.max = other.max
+	}
+	t.count += other.count
+	t.unmerged = append(t.unmerged, other.merged...)
+	t.unmerged = append(t.unmerged, other.unmerged...)
+	t.compress()
+}
+
+// Count returns the total weight of the values added.
+func (t *TDigest) Count() float64 {
+	return t.count
+}
+
+// Min returns the smallest value added.
+func (t *TDigest) Min() float64 {
+	return t.min
+}
+
+// Max returns the largest value added.
+func (t *TDigest) Max() float64 {
+	return t.max
+}
+
+// Reset removes all values from the digest.
+func (t *TDigest) Reset() {
+	t.merged = make(centroids, 0, int(math.Ceil(t.compression))+1)
+	t.unmerged = make(centroids, 0, t.bufferSize)
+	t.count = 0
+	t.min = 0
+	t.max = 0
+}
+
+// Quantile returns the estimated value at quantile q, with 0 <= q <= 1.
+// It returns NaN if no values were added.
+func (t *TDigest) Quantile(q float64) float64 {
+	if t.count == 0 || q < 0 || q > 1 || math.IsNaN(q) {
+		return math.NaN()
+	}
+	t.compress()
+
+	switch {
+	case q == 0:
+		return t.min
+	case q == 1:
+		return t.max
+	case len(t.merged) == 1:
+		return t.merged[0].mean
+	}
+
+	c := t.merged
+
+	index := q * t.count
+	if index < c[0].weight/2 {
+		// between the minimum and the center of the first centroid
+		return t.min + (c[0].mean-t.min)*index/(c[0].weight/2)
+	}
+
+	// the center of each centroid lies at half its weight
+	left := c[0].weight / 2
+	for i := 0; i < len(c)-1; i++ {
+		right := left + c[i].weight/2 + c[i+1].weight/2
+		if index < right {
+			f := (index - left) / (right - left)
+			return c[i].mean + (c[i+1].mean-c[i].mean)*f
+		}
+		left = right
+	}
+
+	// between the center of the last centroid and the maximum
+	last := c[len(c)-1]
+	f := (index - left) / (last.weight / 2)
+	if f > 1 {
+		f = 1
+	}
+	return last.mean + (t.max-last.mean)*f
+}
+
+// compress merges the unmerged values into the centroids. Neighbouring
+// centroids are combined as long as their combined size is within the
+// limit given by the scale function, which allows small centroids near the
+// extreme quantiles and larger ones near the median.
+func (t *TDigest) compress() {
+	if len(t.unmerged) == 0 {
+		return
+	}
+
+	all := append(t.unmerged, t.merged...)
+	sort.Sort(all)
+
+	var total float64
+	for _, c := range all {
+		total += c.weight
+	}
+
+	merged := make(centroids, 0, cap(t.merged))
+	cur := all[0]
+	var before float64
+	limit := t.quantileLimit(0, total)
+	for _, c := range all[1:] {
+		if (before+cur.weight+c.weight)/total <= limit {
+			cur.weight += c.weight
+			cur.mean += (c.mean - cur.mean) * c.weight / cur.weight
+			continue
+		}
+		merged = append(merged, cur)
+		before += cur.weight
+		limit = t.quantileLimit(before, total)
+		cur = c
+	}
+	merged = append(merged, cur)
+
+	t.merged = merged
+	if cap(t.unmerged) > t.bufferSize {
+		t.unmerged = make(centroids, 0, t.bufferSize)
+	} else {
+		t.unmerged = t.unmerged[:0]
+	}
+}
+
+// quantileLimit returns the largest quantile the centroid starting after
+// weight before may extend to. It uses the k1 scale function
+// k(q) = compression/(2*pi) * asin(2q - 1), allowing every centroid to
+// span a k-size of 1.
+func (t *TDigest) quantileLimit(before, total float64) float64 {
+	k := t.compression/(2*math.Pi)*math.Asin(2*before/total-1) + 1
+	if k >= t.compression/4 {
+		return 1
+	}
+	return (math.Sin(k*2*math.Pi/t.compression) + 1) / 2
+}
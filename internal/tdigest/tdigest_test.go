@@ -0,0 +1,98 @@
+package tdigest
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmpty(t *testing.T) {
+	td := New(DefaultCompression)
+	assert.True(t, math.IsNaN(td.Quantile(0.5)))
+	assert.Equal(t, float64(0), td.Count())
+}
+
+func TestSingleValue(t *testing.T) {
+	td := New(DefaultCompression)
+	td.Add(42)
+	assert.Equal(t, float64(42), td.Quantile(0))
+	assert.Equal(t, float64(42), td.Quantile(0.5))
+	assert.Equal(t, float64(42), td.Quantile(1))
+}
+
+func TestSmallSetIsExact(t *testing.T) {
+	td := New(DefaultCompression)
+	for i := 1; i <= 10; i++ {
+		td.Add(float64(i))
+	}
+	assert.Equal(t, float64(1), td.Quantile(0))
+	assert.Equal(t, float64(10), td.Quantile(1))
+	assert.InDelta(t, 5.5, td.Quantile(0.5), 1e-9)
+	assert.Equal(t, float64(10), td.Count())
+}
+
+func checkQuantiles(t *testing.T, td *TDigest, values []float64) {
+	sort.Float64s(values)
+	for _, q := range []float64{0.001, 0.01, 0.1, 0.25, 0.5, 0.75, 0.9, 0.99, 0.999} {
+		expected := values[int(q*float64(len(values)))]
+		// the error is relative to the quantile, and smallest at the tails
+		assert.InDelta(t, expected, td.Quantile(q), 0.01*math.Sqrt(q*(1-q))*1000+0.5,
+			"quantile %v", q)
+	}
+	assert.Equal(t, values[0], td.Min())
+	assert.Equal(t, values[len(values)-1], td.Max())
+}
+
+func TestUniform(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	td := New(DefaultCompression)
+	values := make([]float64, 100000)
+	for i := range values {
+		values[i] = r.Float64() * 1000
+		td.Add(values[i])
+	}
+
+	checkQuantiles(t, td, values)
+	// memory is bounded by the compression
+	assert.True(t, len(td.merged) <= 2*DefaultCompression, "%d centroids", len(td.merged))
+}
+
+func TestMerge(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	a := New(DefaultCompression)
+	b := New(DefaultCompression)
+	values := make([]float64, 50000)
+	for i := range values {
+		values[i] = r.Float64() * 1000
+		if i%2 == 0 {
+			a.Add(values[i])
+		} else {
+			b.Add(values[i])
+		}
+	}
+
+	a.Merge(b)
+	assert.Equal(t, float64(len(values)), a.Count())
+	checkQuantiles(t, a, values)
+}
+
+func TestReset(t *testing.T) {
+	td := New(DefaultCompression)
+	td.Add(1)
+	td.Reset()
+	assert.Equal(t, float64(0), td.Count())
+	td.Add(5)
+	assert.Equal(t, float64(5), td.Min())
+	assert.Equal(t, float64(5), td.Quantile(0.5))
+}
+
+func BenchmarkAdd(b *testing.B) {
+	td := New(DefaultCompression)
+	r := rand.New(rand.NewSource(1))
+	for n := 0; n < b.N; n++ {
+		td.Add(r.Float64())
+	}
+}
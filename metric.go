@@ -128,6 +128,25 @@ func NewCounterMetric(
 	}, nil
 }
 
+// NewTypedMetric returns a metric of the given type, ie to copy a metric
+// with other tags or fields while keeping its type.
+func NewTypedMetric(
+	name string,
+	tags map[string]string,
+	fields map[string]interface{},
+	t time.Time,
+	mType ValueType,
+) (Metric, error) {
+	switch mType {
+	case Counter:
+		return NewCounterMetric(name, tags, fields, t)
+	case Gauge:
+		return NewGaugeMetric(name, tags, fields, t)
+	default:
+		return NewMetric(name, tags, fields, t)
+	}
+}
+
 func (m *metric) Name() string {
 	return m.pt.Name()
 }
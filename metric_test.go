@@ -75,6 +75,23 @@ func TestNewCounterMetric(t *testing.T) {
 	assert.Equal(t, now.UnixNano(), m.UnixNano())
 }
 
+func TestNewTypedMetric(t *testing.T) {
+	now := time.Now()
+
+	tags := map[string]string{"host": "localhost"}
+	fields := map[string]interface{}{"usage_idle": float64(99)}
+	for _, mType := range []ValueType{Untyped, Counter, Gauge} {
+		m, err := NewTypedMetric("cpu", tags, fields, now, mType)
+		assert.NoError(t, err)
+
+		assert.Equal(t, mType, m.Type())
+		assert.Equal(t, "cpu", m.Name())
+		assert.Equal(t, tags, m.Tags())
+		assert.Equal(t, fields, m.Fields())
+		assert.Equal(t, now.UnixNano(), m.UnixNano())
+	}
+}
+
 func TestNewMetricString(t *testing.T) {
 	now := time.Now()
 
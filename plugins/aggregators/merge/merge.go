@@ -0,0 +1,97 @@
+package merge
+
+import (
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/aggregators"
+)
+
+type Merge struct {
+	cache map[seriesTime]*aggregate
+}
+
+func NewMerge() telegraf.Aggregator {
+	m := &Merge{}
+	m.Reset()
+	return m
+}
+
+// seriesTime identifies the metrics to merge, those sharing the same name,
+// tags and timestamp.
+type seriesTime struct {
+	id uint64
+	t  int64
+}
+
+type aggregate struct {
+	name   string
+	tags   map[string]string
+	fields map[string]interface{}
+	mType  telegraf.ValueType
+	time   time.Time
+}
+
+var sampleConfig = `
+  ## General Aggregator Arguments:
+  ## The period on which to flush & clear the aggregator.
+  period = "30s"
+  ## If true, the original metric will be dropped by the
+  ## aggregator and will not get sent to the output plugins.
+  ## This should be true, otherwise every field is sent twice.
+  drop_original = true
+`
+
+func (m *Merge) SampleConfig() string {
+	return sampleConfig
+}
+
+func (m *Merge) Description() string {
+	return "Merge metrics with the same name, tags and timestamp into a single metric."
+}
+
+func (m *Merge) Add(in telegraf.Metric) {
+	key := seriesTime{id: in.HashID(), t: in.UnixNano()}
+	a, ok := m.cache[key]
+	if !ok {
+		m.cache[key] = &aggregate{
+			name:   in.Name(),
+			tags:   in.Tags(),
+			fields: in.Fields(),
+			mType:  in.Type(),
+			time:   in.Time(),
+		}
+		return
+	}
+
+	for k, v := range in.Fields() {
+		a.fields[k] = v
+	}
+	// only keep the type if all merged metrics agree on it
+	if a.mType != in.Type() {
+		a.mType = telegraf.Untyped
+	}
+}
+
+func (m *Merge) Push(acc telegraf.Accumulator) {
+	for _, a := range m.cache {
+		switch a.mType {
+		case telegraf.Counter:
+			acc.AddCounter(a.name, a.fields, a.tags, a.time)
+		case telegraf.Gauge:
+			acc.AddGauge(a.name, a.fields, a.tags, a.time)
+		default:
+			acc.AddFields(a.name, a.fields, a.tags, a.time)
+		}
+	}
+}
+
+func (m *Merge) Reset() {
+	m.cache = make(map[seriesTime]*aggregate)
+}
+
+func init() {
+	aggregators.Add("merge", func() telegraf.Aggregator {
+		return NewMerge()
+	})
+}
@@ -0,0 +1,69 @@
+package merge
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+var now = time.Unix(1479822000, 0)
+
+func newIfTable(fields map[string]interface{}, t time.Time) telegraf.Metric {
+	m, _ := telegraf.NewMetric("interface",
+		map[string]string{"ifName": "eth0"},
+		fields,
+		t,
+	)
+	return m
+}
+
+func TestMerge(t *testing.T) {
+	acc := testutil.Accumulator{}
+	merge := NewMerge()
+
+	merge.Add(newIfTable(map[string]interface{}{"ifInOctets": int64(10)}, now))
+	merge.Add(newIfTable(map[string]interface{}{"ifOutOctets": int64(20)}, now))
+	merge.Add(newIfTable(map[string]interface{}{"ifSpeed": int64(1000)}, now))
+	merge.Push(&acc)
+
+	assert.Len(t, acc.Metrics, 1)
+	acc.AssertContainsTaggedFields(t, "interface",
+		map[string]interface{}{
+			"ifInOctets":  int64(10),
+			"ifOutOctets": int64(20),
+			"ifSpeed":     int64(1000),
+		},
+		map[string]string{"ifName": "eth0"})
+	assert.Equal(t, now, acc.Metrics[0].Time)
+}
+
+func TestMergeKeepsSeriesAndTimesApart(t *testing.T) {
+	acc := testutil.Accumulator{}
+	merge := NewMerge()
+
+	merge.Add(newIfTable(map[string]interface{}{"ifInOctets": int64(10)}, now))
+	merge.Add(newIfTable(map[string]interface{}{"ifInOctets": int64(15)}, now.Add(time.Second)))
+	other, _ := telegraf.NewMetric("interface",
+		map[string]string{"ifName": "eth1"},
+		map[string]interface{}{"ifOutOctets": int64(20)},
+		now,
+	)
+	merge.Add(other)
+	merge.Push(&acc)
+
+	assert.Len(t, acc.Metrics, 3)
+}
+
+func TestMergeReset(t *testing.T) {
+	acc := testutil.Accumulator{}
+	merge := NewMerge()
+
+	merge.Add(newIfTable(map[string]interface{}{"ifInOctets": int64(10)}, now))
+	merge.Reset()
+	merge.Push(&acc)
+
+	assert.Len(t, acc.Metrics, 0)
+}
@@ -0,0 +1,120 @@
+package quantile
+
+import (
+	"strconv"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/tdigest"
+	"github.com/influxdata/telegraf/plugins/aggregators"
+)
+
+type Quantile struct {
+	Quantiles   []float64
+	Compression float64
+
+	cache map[uint64]aggregate
+}
+
+func NewQuantile() telegraf.Aggregator {
+	q := &Quantile{
+		Quantiles:   []float64{0.25, 0.5, 0.75},
+		Compression: tdigest.DefaultCompression,
+	}
+	q.Reset()
+	return q
+}
+
+type aggregate struct {
+	fields map[string]*tdigest.TDigest
+	name   string
+	tags   map[string]string
+}
+
+var sampleConfig = `
+  ## General Aggregator Arguments:
+  ## The period on which to flush & clear the aggregator.
+  period = "30s"
+  ## If true, the original metric will be dropped by the
+  ## aggregator and will not get sent to the output plugins.
+  drop_original = false
+
+  ## Quantiles to output in the range [0,1]. The fields are named
+  ## <field>_p<quantile * 100>, ie "usage_p99" for quantile 0.99.
+  quantiles = [0.25, 0.5, 0.75]
+
+  ## Compression of the t-digest sketch used to estimate the quantiles.
+  ## Higher values are more accurate but use more memory, the sketch keeps
+  ## about this many values per field.
+  compression = 100.0
+`
+
+func (q *Quantile) SampleConfig() string {
+	return sampleConfig
+}
+
+func (q *Quantile) Description() string {
+	return "Keep estimated quantiles of each metric passing through."
+}
+
+func (q *Quantile) Add(in telegraf.Metric) {
+	id := in.HashID()
+	a, ok := q.cache[id]
+	if !ok {
+		a = aggregate{
+			name:   in.Name(),
+			tags:   in.Tags(),
+			fields: make(map[string]*tdigest.TDigest),
+		}
+		q.cache[id] = a
+	}
+
+	for k, v := range in.Fields() {
+		fv, ok := convert(v)
+		if !ok {
+			continue
+		}
+		td, ok := a.fields[k]
+		if !ok {
+			td = tdigest.New(q.Compression)
+			a.fields[k] = td
+		}
+		td.Add(fv)
+	}
+}
+
+func (q *Quantile) Push(acc telegraf.Accumulator) {
+	for _, a := range q.cache {
+		fields := map[string]interface{}{}
+		for k, td := range a.fields {
+			for _, quantile := range q.Quantiles {
+				if quantile < 0 || quantile > 1 {
+					continue
+				}
+				fields[k+"_p"+strconv.FormatFloat(quantile*100, 'f', -1, 64)] =
+					td.Quantile(quantile)
+			}
+		}
+		acc.AddFields(a.name, fields, a.tags)
+	}
+}
+
+func (q *Quantile) Reset() {
+	q.cache = make(map[uint64]aggregate)
+}
+
+func convert(in interface{}) (float64, bool) {
+	switch v := in.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+func init() {
+	aggregators.Add("quantile", func() telegraf.Aggregator {
+		return NewQuantile()
+	})
+}
@@ -0,0 +1,54 @@
+package quantile
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func newMetric(v int64) telegraf.Metric {
+	m, _ := telegraf.NewMetric("http",
+		map[string]string{"path": "/"},
+		map[string]interface{}{
+			"response_time": v,
+			"status":        "ok",
+		},
+		time.Now(),
+	)
+	return m
+}
+
+func TestQuantile(t *testing.T) {
+	acc := testutil.Accumulator{}
+	q := NewQuantile().(*Quantile)
+	q.Quantiles = []float64{0, 0.5, 0.999, 1, 2}
+
+	for i := int64(1); i <= 101; i++ {
+		q.Add(newMetric(i))
+	}
+	q.Push(&acc)
+
+	assert.Len(t, acc.Metrics, 1)
+	m := acc.Metrics[0]
+	assert.Equal(t, "http", m.Measurement)
+	assert.Equal(t, map[string]string{"path": "/"}, m.Tags)
+	assert.Len(t, m.Fields, 4)
+	assert.Equal(t, float64(1), m.Fields["response_time_p0"])
+	assert.InDelta(t, 51, m.Fields["response_time_p50"], 1)
+	assert.InDelta(t, 101, m.Fields["response_time_p99.9"], 1)
+	assert.Equal(t, float64(101), m.Fields["response_time_p100"])
+}
+
+func TestQuantileReset(t *testing.T) {
+	acc := testutil.Accumulator{}
+	q := NewQuantile()
+
+	q.Add(newMetric(1))
+	q.Reset()
+	q.Push(&acc)
+
+	assert.Len(t, acc.Metrics, 0)
+}
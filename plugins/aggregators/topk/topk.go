@@ -0,0 +1,252 @@
+package topk
+
+import (
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/aggregators"
+)
+
+type TopK struct {
+	K           int
+	Field       string
+	Aggregation string
+	GroupBy     []string
+	Bottom      bool
+	AddRankTag  string `toml:"add_rank_tag"`
+
+	cache map[string]*group
+
+	// k and aggregation are the validated K and Aggregation.
+	checked     bool
+	k           int
+	aggregation string
+}
+
+func NewTopK() telegraf.Aggregator {
+	t := &TopK{
+		K:           10,
+		Aggregation: "mean",
+	}
+	t.Reset()
+	return t
+}
+
+// group holds all metrics of a period sharing the same measurement name and
+// group_by tag values, together with the aggregate of the ranked field.
+type group struct {
+	name    string
+	key     string
+	metrics []telegraf.Metric
+
+	count int
+	sum   float64
+	min   float64
+	max   float64
+	last  float64
+}
+
+var sampleConfig = `
+  ## General Aggregator Arguments:
+  ## The period on which to flush & clear the aggregator.
+  period = "10s"
+  ## If true, the original metric will be dropped by the
+  ## aggregator and will not get sent to the output plugins.
+  ## This should usually be true, so that only the top k series are sent.
+  drop_original = true
+
+  ## Number of groups to forward per measurement and period.
+  k = 10
+
+  ## Field used to rank the groups.
+  field = "cpu_usage"
+
+  ## Aggregation of the field over the period used for ranking,
+  ## can be one of "mean", "sum", "min", "max" or "last".
+  aggregation = "mean"
+
+  ## Tags used to group the series. Series with the same measurement name and
+  ## values for these tags are ranked together. If empty, every series is its
+  ## own group.
+  group_by = ["process_name"]
+
+  ## If true, forward the bottom k groups instead of the top k.
+  bottom = false
+
+  ## If set, the rank of the group is added to the metrics as a tag with this
+  ## name, starting at 1.
+  # add_rank_tag = "rank"
+`
+
+func (t *TopK) SampleConfig() string {
+	return sampleConfig
+}
+
+func (t *TopK) Description() string {
+	return "Forward only the top or bottom k series of each period, ranked by a field."
+}
+
+func (t *TopK) Add(in telegraf.Metric) {
+	key := t.groupKey(in)
+	g, ok := t.cache[key]
+	if !ok {
+		g = &group{name: in.Name(), key: key}
+		t.cache[key] = g
+	}
+	g.metrics = append(g.metrics, in)
+
+	fv, ok := convert(in.Fields()[t.Field])
+	if !ok {
+		return
+	}
+	if g.count == 0 || fv < g.min {
+		g.min = fv
+	}
+	if g.count == 0 || fv > g.max {
+		g.max = fv
+	}
+	g.sum += fv
+	g.last = fv
+	g.count++
+}
+
+func (t *TopK) Push(acc telegraf.Accumulator) {
+	t.check()
+
+	// rank the groups of each measurement separately
+	byName := make(map[string][]*group)
+	for _, g := range t.cache {
+		if g.count == 0 {
+			continue
+		}
+		byName[g.name] = append(byName[g.name], g)
+	}
+
+	for _, groups := range byName {
+		r := &ranking{groups: groups, values: make([]float64, len(groups))}
+		for i, g := range groups {
+			r.values[i] = t.aggregate(g)
+		}
+		if t.Bottom {
+			sort.Sort(r)
+		} else {
+			sort.Sort(sort.Reverse(r))
+		}
+
+		if len(groups) > t.k {
+			groups = groups[:t.k]
+		}
+		for i, g := range groups {
+			for _, m := range g.metrics {
+				tags := m.Tags()
+				if t.AddRankTag != "" {
+					tags[t.AddRankTag] = strconv.Itoa(i + 1)
+				}
+				switch m.Type() {
+				case telegraf.Counter:
+					acc.AddCounter(m.Name(), m.Fields(), tags, m.Time())
+				case telegraf.Gauge:
+					acc.AddGauge(m.Name(), m.Fields(), tags, m.Time())
+				default:
+					acc.AddFields(m.Name(), m.Fields(), tags, m.Time())
+				}
+			}
+		}
+	}
+}
+
+// ranking sorts groups by their aggregated value in ascending order, ties are
+// broken by the group key so that the result is deterministic.
+type ranking struct {
+	groups []*group
+	values []float64
+}
+
+func (r *ranking) Len() int { return len(r.groups) }
+func (r *ranking) Swap(i, j int) {
+	r.groups[i], r.groups[j] = r.groups[j], r.groups[i]
+	r.values[i], r.values[j] = r.values[j], r.values[i]
+}
+func (r *ranking) Less(i, j int) bool {
+	if r.values[i] == r.values[j] {
+		return r.groups[i].key > r.groups[j].key
+	}
+	return r.values[i] < r.values[j]
+}
+
+func (t *TopK) Reset() {
+	t.cache = make(map[string]*group)
+}
+
+// groupKey returns the key of the group the metric belongs to.
+func (t *TopK) groupKey(m telegraf.Metric) string {
+	if len(t.GroupBy) == 0 {
+		return m.Name() + "\x00" + strconv.FormatUint(m.HashID(), 10)
+	}
+
+	tags := m.Tags()
+	parts := make([]string, 0, len(t.GroupBy)+1)
+	parts = append(parts, m.Name())
+	for _, tag := range t.GroupBy {
+		parts = append(parts, tags[tag])
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// check validates the configuration once. Invalid values are logged and
+// replaced by the closest valid ones.
+func (t *TopK) check() {
+	if t.checked {
+		return
+	}
+	t.checked = true
+
+	t.k = t.K
+	if t.k < 1 {
+		log.Printf("E! topk: invalid k %d, using 1\n", t.K)
+		t.k = 1
+	}
+
+	switch t.Aggregation {
+	case "sum", "min", "max", "last", "mean":
+		t.aggregation = t.Aggregation
+	default:
+		log.Printf("E! topk: invalid aggregation %q, using mean\n", t.Aggregation)
+		t.aggregation = "mean"
+	}
+}
+
+func (t *TopK) aggregate(g *group) float64 {
+	switch t.aggregation {
+	case "sum":
+		return g.sum
+	case "min":
+		return g.min
+	case "max":
+		return g.max
+	case "last":
+		return g.last
+	default:
+		return g.sum / float64(g.count)
+	}
+}
+
+func convert(in interface{}) (float64, bool) {
+	switch v := in.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+func init() {
+	aggregators.Add("topk", func() telegraf.Aggregator {
+		return NewTopK()
+	})
+}
@@ -0,0 +1,127 @@
+package topk
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func newProcstat(name, pid string, cpu float64) telegraf.Metric {
+	m, _ := telegraf.NewMetric("procstat",
+		map[string]string{"process_name": name, "pid": pid},
+		map[string]interface{}{"cpu_usage": cpu},
+		time.Now(),
+	)
+	return m
+}
+
+func addAll(a telegraf.Aggregator) {
+	a.Add(newProcstat("nginx", "1", 10))
+	a.Add(newProcstat("nginx", "2", 30))
+	a.Add(newProcstat("java", "3", 50))
+	a.Add(newProcstat("java", "3", 10))
+	a.Add(newProcstat("sshd", "4", 1))
+	a.Add(newProcstat("sshd", "4", 2))
+}
+
+func processNames(acc *testutil.Accumulator) map[string]int {
+	names := make(map[string]int)
+	for _, m := range acc.Metrics {
+		names[m.Tags["process_name"]]++
+	}
+	return names
+}
+
+func TestTopKMeanGroupBy(t *testing.T) {
+	acc := testutil.Accumulator{}
+	topk := NewTopK().(*TopK)
+	topk.K = 1
+	topk.Field = "cpu_usage"
+	topk.GroupBy = []string{"process_name"}
+
+	addAll(topk)
+	topk.Push(&acc)
+
+	assert.Equal(t, map[string]int{"java": 2}, processNames(&acc))
+}
+
+func TestTopKMaxPerSeries(t *testing.T) {
+	acc := testutil.Accumulator{}
+	topk := NewTopK().(*TopK)
+	topk.K = 2
+	topk.Field = "cpu_usage"
+	topk.Aggregation = "max"
+	topk.AddRankTag = "rank"
+
+	addAll(topk)
+	topk.Push(&acc)
+
+	assert.Len(t, acc.Metrics, 3)
+	acc.AssertContainsTaggedFields(t, "procstat",
+		map[string]interface{}{"cpu_usage": float64(50)},
+		map[string]string{"process_name": "java", "pid": "3", "rank": "1"})
+	acc.AssertContainsTaggedFields(t, "procstat",
+		map[string]interface{}{"cpu_usage": float64(30)},
+		map[string]string{"process_name": "nginx", "pid": "2", "rank": "2"})
+}
+
+func TestBottomKLast(t *testing.T) {
+	acc := testutil.Accumulator{}
+	topk := NewTopK().(*TopK)
+	topk.K = 1
+	topk.Field = "cpu_usage"
+	topk.Aggregation = "last"
+	topk.GroupBy = []string{"process_name"}
+	topk.Bottom = true
+
+	addAll(topk)
+	topk.Push(&acc)
+
+	assert.Equal(t, map[string]int{"sshd": 2}, processNames(&acc))
+}
+
+func TestTopKReset(t *testing.T) {
+	acc := testutil.Accumulator{}
+	topk := NewTopK().(*TopK)
+	topk.Field = "cpu_usage"
+
+	addAll(topk)
+	topk.Reset()
+	topk.Push(&acc)
+
+	assert.Len(t, acc.Metrics, 0)
+}
+
+func TestTopKInvalidK(t *testing.T) {
+	acc := testutil.Accumulator{}
+	topk := NewTopK().(*TopK)
+	topk.K = -1
+	topk.Field = "cpu_usage"
+	topk.GroupBy = []string{"process_name"}
+
+	addAll(topk)
+	topk.Push(&acc)
+
+	// k is clamped to 1
+	assert.Equal(t, map[string]int{"java": 2}, processNames(&acc))
+	assert.Equal(t, -1, topk.K)
+}
+
+func TestTopKInvalidAggregation(t *testing.T) {
+	acc := testutil.Accumulator{}
+	topk := NewTopK().(*TopK)
+	topk.K = 1
+	topk.Field = "cpu_usage"
+	topk.Aggregation = "median"
+	topk.GroupBy = []string{"process_name"}
+
+	addAll(topk)
+	topk.Push(&acc)
+
+	// the mean is used, the configuration is left untouched
+	assert.Equal(t, map[string]int{"java": 2}, processNames(&acc))
+	assert.Equal(t, "median", topk.Aggregation)
+}
@@ -8,7 +8,6 @@ import (
 	"runtime"
 	"strings"
 	"sync"
-	"syscall"
 	"time"
 
 	"github.com/gonuts/go-shellquote"
@@ -66,23 +65,19 @@ type Runner interface {
 
 type CommandRunner struct{}
 
+// AddNagiosState adds the nagios_state metric with the exit state of a
+// nagios plugin.
+//
+// Deprecated: use nagios.TryAddState, which adds the state to the metrics of
+// the parsed output.
 func AddNagiosState(exitCode error, acc telegraf.Accumulator) error {
-	nagiosState := 0
-	if exitCode != nil {
-		exiterr, ok := exitCode.(*exec.ExitError)
-		if ok {
-			status, ok := exiterr.Sys().(syscall.WaitStatus)
-			if ok {
-				nagiosState = status.ExitStatus()
-			} else {
-				return fmt.Errorf("exec: unable to get nagios plugin exit code")
-			}
-		} else {
-			return fmt.Errorf("exec: unable to get nagios plugin exit code")
-		}
+	metrics, err := nagios.TryAddState(exitCode, nil)
+	if err != nil {
+		return fmt.Errorf("exec: %s", err)
+	}
+	for _, metric := range metrics {
+		acc.AddFields(metric.Name(), metric.Fields(), metric.Tags(), metric.Time())
 	}
-	fields := map[string]interface{}{"state": nagiosState}
-	acc.AddFields("nagios_state", fields, nil)
 	return nil
 }
 
@@ -101,22 +96,19 @@ func (c CommandRunner) Run(
 	var out bytes.Buffer
 	cmd.Stdout = &out
 
-	if err := internal.RunTimeout(cmd, e.Timeout.Duration); err != nil {
+	runErr := internal.RunTimeout(cmd, e.Timeout.Duration)
+	if runErr != nil {
 		switch e.parser.(type) {
 		case *nagios.NagiosParser:
-			AddNagiosState(err, acc)
+			// The exit code of nagios plugins is their state, the
+			// output is still parsed.
 		default:
-			return nil, fmt.Errorf("exec: %s for command '%s'", err, command)
-		}
-	} else {
-		switch e.parser.(type) {
-		case *nagios.NagiosParser:
-			AddNagiosState(nil, acc)
+			return nil, fmt.Errorf("exec: %s for command '%s'", runErr, command)
 		}
 	}
 
 	out = removeCarriageReturns(out)
-	return out.Bytes(), nil
+	return out.Bytes(), runErr
 }
 
 // removeCarriageReturns removes all carriage returns from the input if the
@@ -148,17 +140,34 @@ func removeCarriageReturns(b bytes.Buffer) bytes.Buffer {
 func (e *Exec) ProcessCommand(command string, acc telegraf.Accumulator, wg *sync.WaitGroup) {
 	defer wg.Done()
 
-	out, err := e.runner.Run(e, command, acc)
-	if err != nil {
-		e.errChan <- err
+	_, isNagios := e.parser.(*nagios.NagiosParser)
+
+	out, runErr := e.runner.Run(e, command, acc)
+	if runErr != nil && !isNagios {
+		e.errChan <- runErr
 		return
 	}
 
 	metrics, err := e.parser.Parse(out)
 	if err != nil {
 		e.errChan <- err
-	} else {
-		for _, metric := range metrics {
+		return
+	}
+
+	if isNagios {
+		metrics, err = nagios.TryAddState(runErr, metrics)
+		if err != nil {
+			e.errChan <- fmt.Errorf("exec: %s for command '%s': %s", runErr, command, err)
+		}
+	}
+
+	for _, metric := range metrics {
+		switch metric.Type() {
+		case telegraf.Counter:
+			acc.AddCounter(metric.Name(), metric.Fields(), metric.Tags(), metric.Time())
+		case telegraf.Gauge:
+			acc.AddGauge(metric.Name(), metric.Fields(), metric.Tags(), metric.Time())
+		default:
 			acc.AddFields(metric.Name(), metric.Fields(), metric.Tags(), metric.Time())
 		}
 	}
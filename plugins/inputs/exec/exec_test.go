@@ -243,6 +243,37 @@ func TestExecCommandWithoutGlobAndPath(t *testing.T) {
 	acc.AssertContainsFields(t, "metric", fields)
 }
 
+func TestExecNagiosState(t *testing.T) {
+	parser, _ := parsers.NewNagiosParser()
+	e := NewExec()
+	e.Commands = []string{`/bin/sh -c "echo 'LOAD WARNING|load1=5;4;6'; exit 1"`}
+	e.SetParser(parser)
+
+	var acc testutil.Accumulator
+	err := e.Gather(&acc)
+	require.NoError(t, err)
+
+	acc.AssertContainsFields(t, "load1", map[string]interface{}{
+		"value":    float64(5),
+		"warning":  float64(4),
+		"critical": float64(6),
+	})
+	acc.AssertContainsFields(t, "nagios_state", map[string]interface{}{
+		"service_output": "LOAD WARNING",
+		"state":          int64(1),
+	})
+}
+
+func TestAddNagiosState(t *testing.T) {
+	var acc testutil.Accumulator
+	require.NoError(t, AddNagiosState(nil, &acc))
+	acc.AssertContainsFields(t, "nagios_state", map[string]interface{}{
+		"state": int64(0),
+	})
+
+	assert.Error(t, AddNagiosState(fmt.Errorf("timeout"), &acc))
+}
+
 func TestRemoveCarriageReturns(t *testing.T) {
 	if runtime.GOOS == "windows" {
 		// Test that all carriage returns are removed
@@ -4,6 +4,8 @@ import (
 	"math"
 	"math/rand"
 	"sort"
+
+	"github.com/influxdata/telegraf/internal/tdigest"
 )
 
 const defaultPercentileLimit = 1000
@@ -24,6 +26,11 @@ type RunningStats struct {
 	perc      []float64
 	PercLimit int
 
+	// If Sketch is true, percentiles are estimated with a t-digest instead,
+	// which uses bounded memory and is accurate for any number of values.
+	Sketch bool
+	digest *tdigest.TDigest
+
 	upper float64
 	lower float64
 
@@ -40,10 +47,14 @@ func (rs *RunningStats) AddValue(v float64) {
 		rs.k = v
 		rs.upper = v
 		rs.lower = v
-		if rs.PercLimit == 0 {
-			rs.PercLimit = defaultPercentileLimit
+		if rs.Sketch {
+			rs.digest = tdigest.New(tdigest.DefaultCompression)
+		} else {
+			if rs.PercLimit == 0 {
+				rs.PercLimit = defaultPercentileLimit
+			}
+			rs.perc = make([]float64, 0, rs.PercLimit)
 		}
-		rs.perc = make([]float64, 0, rs.PercLimit)
 	}
 
 	// These are used for the running mean and variance
@@ -58,7 +69,9 @@ func (rs *RunningStats) AddValue(v float64) {
 		rs.lower = v
 	}
 
-	if len(rs.perc) < rs.PercLimit {
+	if rs.digest != nil {
+		rs.digest.Add(v)
+	} else if len(rs.perc) < rs.PercLimit {
 		rs.perc = append(rs.perc, v)
 	} else {
 		// Reached limit, choose random index to overwrite in the percentile array
@@ -95,6 +108,10 @@ func (rs *RunningStats) Percentile(n int) float64 {
 		n = 100
 	}
 
+	if rs.digest != nil {
+		return rs.digest.Quantile(float64(n) / 100)
+	}
+
 	if !rs.sorted {
 		sort.Float64s(rs.perc)
 		rs.sorted = true
@@ -134,3 +134,28 @@ func fuzzyEqual(a, b, epsilon float64) bool {
 	}
 	return true
 }
+
+// Test that percentiles are estimated by the sketch beyond the limit.
+func TestRunningStats_PercentileSketch(t *testing.T) {
+	rs := RunningStats{Sketch: true, PercLimit: 10}
+
+	for i := 1; i <= 1000; i++ {
+		rs.AddValue(float64(i))
+	}
+
+	if rs.Count() != 1000 {
+		t.Errorf("Expected %v, got %v", 1000, rs.Count())
+	}
+	if len(rs.perc) != 0 {
+		t.Errorf("Expected %v, got %v", 0, len(rs.perc))
+	}
+	if !fuzzyEqual(rs.Percentile(90), 900, 2) {
+		t.Errorf("Expected %v, got %v", 900, rs.Percentile(90))
+	}
+	if !fuzzyEqual(rs.Percentile(50), 500, 2) {
+		t.Errorf("Expected %v, got %v", 500, rs.Percentile(50))
+	}
+	if rs.Percentile(100) != 1000 {
+		t.Errorf("Expected %v, got %v", 1000, rs.Percentile(100))
+	}
+}
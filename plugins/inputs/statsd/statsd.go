@@ -46,6 +46,9 @@ type Statsd struct {
 	// and histogram stats.
 	Percentiles     []int
 	PercentileLimit int
+	// PercentileSketch estimates percentiles with a t-digest sketch instead
+	// of a random sample of PercentileLimit values.
+	PercentileSketch bool
 
 	DeleteGauges   bool
 	DeleteCounters bool
@@ -58,6 +61,9 @@ type Statsd struct {
 	// This flag enables parsing of tags in the dogstatsd extention to the
 	// statsd protocol (http://docs.datadoghq.com/guides/dogstatsd/)
 	ParseDataDogTags bool
+	// This flag enables parsing of graphite tagged series in the bucket
+	// names, ie "cpu.load;host=a:1|g".
+	GraphiteTagSupport bool
 
 	// UDPPacketSize is deprecated, it's only here for legacy support
 	// we now always create 1 max size buffer and then copy only what we need
@@ -153,6 +159,9 @@ const sampleConfig = `
   ## http://docs.datadoghq.com/guides/dogstatsd/
   parse_data_dog_tags = false
 
+  ## Parses graphite tags in the bucket names, ie "cpu.load;host=a:1|g"
+  graphite_tag_support = false
+
   ## Statsd data translation templates, more info can be read here:
   ## https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_INPUT.md#graphite
   # templates = [
@@ -167,6 +176,11 @@ const sampleConfig = `
   ## calculation of percentiles. Raising this limit increases the accuracy
   ## of percentiles but also increases the memory usage and cpu time.
   percentile_limit = 1000
+
+  ## Estimate percentiles with a t-digest sketch instead of a sample of
+  ## percentile_limit values. The sketch uses bounded memory and stays
+  ## accurate for any number of values.
+  percentile_sketch = false
 `
 
 func (_ *Statsd) SampleConfig() string {
@@ -514,6 +528,9 @@ func (s *Statsd) parseName(bucket string) (string, string, map[string]string) {
 
 	if p == nil || s.graphiteParser.Separator != s.MetricSeparator {
 		p, err = graphite.NewGraphiteParser(s.MetricSeparator, s.Templates, nil)
+		if err == nil {
+			p.TagSupport = s.GraphiteTagSupport
+		}
 		s.graphiteParser = p
 	}
 
@@ -570,6 +587,7 @@ func (s *Statsd) aggregate(m metric) {
 		if !ok {
 			field = RunningStats{
 				PercLimit: s.PercentileLimit,
+				Sketch:    s.PercentileSketch,
 			}
 		}
 		if m.samplerate > 0 {
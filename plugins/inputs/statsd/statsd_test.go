@@ -3,6 +3,7 @@ package statsd
 import (
 	"errors"
 	"fmt"
+	"reflect"
 	"testing"
 
 	"github.com/influxdata/telegraf/testutil"
@@ -744,6 +745,31 @@ func TestParse_DataDogTags(t *testing.T) {
 	}
 }
 
+func TestParse_GraphiteTags(t *testing.T) {
+	s := NewTestStatsd()
+	s.GraphiteTagSupport = true
+
+	err := s.parseStatsdLine("cpu.load;host=localhost;region=us-west:1|g")
+	if err != nil {
+		t.Errorf("Parsing line should not have resulted in an error: %s", err)
+	}
+
+	name, _, tags := s.parseName("cpu.load;host=localhost,dc=east")
+	if name != "cpu_load" {
+		t.Errorf("Expected: cpu_load, got %s", name)
+	}
+	expected := map[string]string{"host": "localhost", "dc": "east"}
+	if !reflect.DeepEqual(tags, expected) {
+		t.Errorf("Expected: %v, got %v", expected, tags)
+	}
+
+	tags = tagsForItem(s.gauges)
+	expected = map[string]string{"host": "localhost", "region": "us-west", "metric_type": "gauge"}
+	if !reflect.DeepEqual(tags, expected) {
+		t.Errorf("Expected: %v, got %v", expected, tags)
+	}
+}
+
 func tagsForItem(m interface{}) map[string]string {
 	switch m.(type) {
 	case map[string]cachedcounter:
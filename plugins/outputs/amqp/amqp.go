@@ -1,7 +1,6 @@
 package amqp
 
 import (
-	"bytes"
 	"fmt"
 	"log"
 	"strings"
@@ -182,7 +181,7 @@ func (q *AMQP) Write(metrics []telegraf.Metric) error {
 	if len(metrics) == 0 {
 		return nil
 	}
-	var outbuf = make(map[string][][]byte)
+	var batches = make(map[string][]telegraf.Metric)
 
 	for _, metric := range metrics {
 		var key string
@@ -191,19 +190,16 @@ func (q *AMQP) Write(metrics []telegraf.Metric) error {
 				key = h
 			}
 		}
+		batches[key] = append(batches[key], metric)
+	}
 
-		values, err := q.serializer.Serialize(metric)
+	serializer := serializers.NewBatchSerializer(q.serializer)
+	for key, batch := range batches {
+		body, err := serializer.SerializeBatch(batch)
 		if err != nil {
 			return err
 		}
-
-		for _, value := range values {
-			outbuf[key] = append(outbuf[key], []byte(value))
-		}
-	}
-
-	for key, buf := range outbuf {
-		err := q.channel.Publish(
+		err = q.channel.Publish(
 			q.Exchange, // exchange
 			key,        // routing key
 			false,      // mandatory
@@ -211,7 +207,7 @@ func (q *AMQP) Write(metrics []telegraf.Metric) error {
 			amqp.Publishing{
 				Headers:     q.headers,
 				ContentType: "text/plain",
-				Body:        bytes.Join(buf, []byte("\n")),
+				Body:        body,
 			})
 		if err != nil {
 			return fmt.Errorf("FAILED to send amqp message: %s", err)
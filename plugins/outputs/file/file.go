@@ -1,28 +1,67 @@
 package file
 
 import (
+	"bytes"
 	"fmt"
 	"io"
+	"log"
 	"os"
+	"strings"
+	"text/template"
+	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/plugins/outputs"
 	"github.com/influxdata/telegraf/plugins/serializers"
 )
 
+// partitionIdleTimeout is the time after which the files of a template which
+// are not written to are closed.
+const partitionIdleTimeout = time.Minute
+
+// maxOpenPartitions is the number of files of the templates kept open, the
+// least recently written one being closed to open another.
+const maxOpenPartitions = 64
+
 type File struct {
 	Files []string
 
+	RotationInterval    internal.Duration `toml:"rotation_interval"`
+	RotationMaxSize     int64             `toml:"rotation_max_size"`
+	RotationMaxArchives int               `toml:"rotation_max_archives"`
+	RotationCompress    bool              `toml:"rotation_compress"`
+
 	writer  io.Writer
 	closers []io.Closer
 
+	// Files whose name is a template, and the files opened from them which
+	// are not idle.
+	templates []*template.Template
+	partition map[string]*rotatingFile
+
 	serializer serializers.Serializer
 }
 
 var sampleConfig = `
   ## Files to write to, "stdout" is a specially handled file.
+  ## File names may be templates of the measurement name, tags and time of
+  ## the metrics, partitioning the metrics into several files, ie:
+  ##   "/var/log/telegraf/{{.Name}}-{{.Tags.host}}-{{.Time.Format \"2006-01-02\"}}.out"
   files = ["stdout", "/tmp/metrics.out"]
 
+  ## Rotate the files when they are older than the interval, or when they
+  ## would grow larger than the size in bytes. 0 disables the rotation.
+  # rotation_interval = "0s"
+  # rotation_max_size = 0
+
+  ## Number of rotated files to keep, the oldest ones are removed. 0 keeps
+  ## all the rotated files.
+  # rotation_max_archives = 0
+
+  ## Gzip the rotated files.
+  # rotation_compress = false
+
   ## Data format to output.
   ## Each data format has it's own unique set of configuration options, read
   ## more about them here:
@@ -30,30 +69,41 @@ var sampleConfig = `
   data_format = "influx"
 `
 
+// fileName holds the values available to the templates of the file names.
+type fileName struct {
+	Name string
+	Tags map[string]string
+	Time time.Time
+}
+
 func (f *File) SetSerializer(serializer serializers.Serializer) {
 	f.serializer = serializer
 }
 
 func (f *File) Connect() error {
 	writers := []io.Writer{}
+	f.writer = nil
+	f.closers = nil
+	f.templates = nil
 
 	if len(f.Files) == 0 {
 		f.Files = []string{"stdout"}
 	}
 
+	f.partition = make(map[string]*rotatingFile)
 	for _, file := range f.Files {
-		if file == "stdout" {
+		switch {
+		case file == "stdout":
 			writers = append(writers, os.Stdout)
 			f.closers = append(f.closers, os.Stdout)
-		} else {
-			var of *os.File
-			var err error
-			if _, err := os.Stat(file); os.IsNotExist(err) {
-				of, err = os.Create(file)
-			} else {
-				of, err = os.OpenFile(file, os.O_APPEND|os.O_WRONLY, os.ModeAppend)
+		case strings.Contains(file, "{{"):
+			tmpl, err := template.New(file).Option("missingkey=zero").Parse(file)
+			if err != nil {
+				return fmt.Errorf("invalid file name template %s: %s", file, err)
 			}
-
+			f.templates = append(f.templates, tmpl)
+		default:
+			of, err := f.open(file)
 			if err != nil {
 				return err
 			}
@@ -61,10 +111,17 @@ func (f *File) Connect() error {
 			f.closers = append(f.closers, of)
 		}
 	}
-	f.writer = io.MultiWriter(writers...)
+	if len(writers) > 0 {
+		f.writer = io.MultiWriter(writers...)
+	}
 	return nil
 }
 
+func (f *File) open(file string) (*rotatingFile, error) {
+	return openRotatingFile(file, f.RotationInterval.Duration,
+		f.RotationMaxSize, f.RotationMaxArchives, f.RotationCompress)
+}
+
 func (f *File) Close() error {
 	var errS string
 	for _, c := range f.closers {
@@ -72,6 +129,12 @@ func (f *File) Close() error {
 			errS += err.Error() + "\n"
 		}
 	}
+	for _, c := range f.partition {
+		if err := c.Close(); err != nil {
+			errS += err.Error() + "\n"
+		}
+	}
+	f.partition = make(map[string]*rotatingFile)
 	if errS != "" {
 		return fmt.Errorf(errS)
 	}
@@ -91,22 +154,133 @@ func (f *File) Write(metrics []telegraf.Metric) error {
 		return nil
 	}
 
-	for _, metric := range metrics {
-		values, err := f.serializer.Serialize(metric)
+	if f.writer != nil {
+		b, err := serializers.NewBatchSerializer(f.serializer).SerializeBatch(metrics)
 		if err != nil {
 			return err
 		}
 
-		for _, value := range values {
-			_, err = f.writer.Write([]byte(value + "\n"))
+		_, err = f.writer.Write(b)
+		if err != nil {
+			return fmt.Errorf("FAILED to write message: %s", err)
+		}
+	}
+
+	if len(f.templates) > 0 {
+		return f.writePartitions(metrics)
+	}
+	return nil
+}
+
+// writePartitions writes the metrics to the files named by the templates.
+// The files which have not been written to for partitionIdleTimeout are
+// closed, as are the least recently written ones beyond maxOpenPartitions.
+// Metrics which cannot be named a file, ie because of a missing tag naming a
+// directory, are logged and skipped.
+func (f *File) writePartitions(metrics []telegraf.Metric) error {
+	var names []string
+	partitions := make(map[string][]telegraf.Metric)
+	for _, metric := range metrics {
+		for _, tmpl := range f.templates {
+			name, err := executeName(tmpl, metric)
 			if err != nil {
-				return fmt.Errorf("FAILED to write message: %s, %s", value, err)
+				log.Printf("E! file: unable to name the file of %s: %s\n", metric.Name(), err)
+				continue
+			}
+			if !validName(name) {
+				log.Printf("E! file: invalid file name %q for %s, skipping it\n", name, metric.Name())
+				continue
 			}
+			if _, ok := partitions[name]; !ok {
+				names = append(names, name)
+			}
+			partitions[name] = append(partitions[name], metric)
+		}
+	}
+
+	for name, of := range f.partition {
+		if _, ok := partitions[name]; !ok && time.Since(of.written) >= partitionIdleTimeout {
+			of.Close()
+			delete(f.partition, name)
+		}
+	}
+
+	for _, name := range names {
+		of, ok := f.partition[name]
+		if !ok {
+			if len(f.partition) >= maxOpenPartitions {
+				f.closeOldestPartition()
+			}
+			var err error
+			of, err = f.open(name)
+			if err != nil {
+				return err
+			}
+			f.partition[name] = of
+		}
+
+		b, err := serializers.NewBatchSerializer(f.serializer).SerializeBatch(partitions[name])
+		if err != nil {
+			return err
+		}
+		if _, err := of.Write(b); err != nil {
+			return fmt.Errorf("FAILED to write message: %s", err)
 		}
 	}
 	return nil
 }
 
+// closeOldestPartition closes the least recently written file of the
+// templates.
+func (f *File) closeOldestPartition() {
+	var oldest string
+	for name, of := range f.partition {
+		if oldest == "" || of.written.Before(f.partition[oldest].written) {
+			oldest = name
+		}
+	}
+	if oldest != "" {
+		f.partition[oldest].Close()
+		delete(f.partition, oldest)
+	}
+}
+
+// validName reports whether a name rendered by a template names a file.
+func validName(name string) bool {
+	return name != "" &&
+		!strings.HasSuffix(name, "/") &&
+		!strings.HasSuffix(name, string(os.PathSeparator))
+}
+
+func executeName(tmpl *template.Template, metric telegraf.Metric) (string, error) {
+	tags := make(map[string]string)
+	for k, v := range metric.Tags() {
+		tags[k] = sanitize(v)
+	}
+	data := fileName{
+		Name: sanitize(metric.Name()),
+		Tags: tags,
+		Time: metric.Time(),
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// sanitize keeps the values of the metrics from naming files outside of
+// the directory of the template.
+func sanitize(s string) string {
+	s = strings.Replace(s, string(os.PathSeparator), "_", -1)
+	s = strings.Replace(s, "/", "_", -1)
+	if s == "." || s == ".." {
+		return "_"
+	}
+	return s
+}
+
 func init() {
 	outputs.Add("file", func() telegraf.Output {
 		return &File{}
@@ -2,13 +2,19 @@ package file
 
 import (
 	"bytes"
+	"compress/gzip"
 	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/plugins/serializers"
 	"github.com/influxdata/telegraf/testutil"
@@ -194,3 +200,226 @@ func validateFile(fname, expS string, t *testing.T) {
 	}
 	assert.Equal(t, expS, string(buf))
 }
+
+func TestFileRotationMaxSize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	s, _ := serializers.NewInfluxSerializer()
+	f := File{
+		Files:               []string{filepath.Join(dir, "metrics.out")},
+		RotationMaxSize:     int64(len(expNewFile)) + 1,
+		RotationMaxArchives: 2,
+		serializer:          s,
+	}
+	require.NoError(t, f.Connect())
+
+	for i := 0; i < 4; i++ {
+		require.NoError(t, f.Write(testutil.MockMetrics()))
+	}
+	require.NoError(t, f.Close())
+
+	validateFile(filepath.Join(dir, "metrics.out"), expNewFile, t)
+	archives, err := filepath.Glob(filepath.Join(dir, "metrics.out.*"))
+	require.NoError(t, err)
+	require.Len(t, archives, 2)
+	for _, archive := range archives {
+		validateFile(archive, expNewFile, t)
+	}
+}
+
+func TestFileRotationIntervalCompress(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	s, _ := serializers.NewInfluxSerializer()
+	f := File{
+		Files:            []string{filepath.Join(dir, "metrics.out")},
+		RotationInterval: internal.Duration{Duration: time.Hour},
+		RotationCompress: true,
+		serializer:       s,
+	}
+	require.NoError(t, f.Connect())
+
+	require.NoError(t, f.Write(testutil.MockMetrics()))
+	require.NoError(t, f.Write(testutil.MockMetrics()))
+
+	f.closers[0].(*rotatingFile).opened = time.Now().Add(-2 * time.Hour)
+	require.NoError(t, f.Write(testutil.MockMetrics()))
+	require.NoError(t, f.Close())
+
+	validateFile(filepath.Join(dir, "metrics.out"), expNewFile, t)
+	archives, err := filepath.Glob(filepath.Join(dir, "metrics.out.*.gz"))
+	require.NoError(t, err)
+	require.Len(t, archives, 1)
+
+	fh, err := os.Open(archives[0])
+	require.NoError(t, err)
+	defer fh.Close()
+	gz, err := gzip.NewReader(fh)
+	require.NoError(t, err)
+	buf, err := ioutil.ReadAll(gz)
+	require.NoError(t, err)
+	assert.Equal(t, expNewFile+expNewFile, string(buf))
+}
+
+func TestFileTemplate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	s, _ := serializers.NewInfluxSerializer()
+	f := File{
+		Files: []string{filepath.Join(dir,
+			`{{.Name}}/{{.Tags.host}}-{{.Time.Format "2006-01-02"}}.out`)},
+		serializer: s,
+	}
+	require.NoError(t, f.Connect())
+
+	now := time.Date(2016, 11, 22, 13, 0, 0, 0, time.UTC)
+	m1, _ := telegraf.NewMetric("cpu", map[string]string{"host": "a"},
+		map[string]interface{}{"value": 1.0}, now)
+	m2, _ := telegraf.NewMetric("cpu", map[string]string{"host": "../b"},
+		map[string]interface{}{"value": 2.0}, now)
+	m3, _ := telegraf.NewMetric("mem", map[string]string{"host": "a"},
+		map[string]interface{}{"value": 3.0}, now)
+	require.NoError(t, f.Write([]telegraf.Metric{m1, m2, m3}))
+	require.NoError(t, f.Close())
+
+	validateFile(filepath.Join(dir, "cpu", "a-2016-11-22.out"),
+		"cpu,host=a value=1 1479819600000000000\n", t)
+	validateFile(filepath.Join(dir, "cpu", ".._b-2016-11-22.out"),
+		"cpu,host=../b value=2 1479819600000000000\n", t)
+	validateFile(filepath.Join(dir, "mem", "a-2016-11-22.out"),
+		"mem,host=a value=3 1479819600000000000\n", t)
+}
+
+func TestFileRotationIntervalExistingFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "metrics.out")
+	require.NoError(t, ioutil.WriteFile(path, []byte(expNewFile), 0666))
+	old := time.Now().Add(-2 * time.Hour)
+	require.NoError(t, os.Chtimes(path, old, old))
+
+	s, _ := serializers.NewInfluxSerializer()
+	f := File{
+		Files:            []string{path},
+		RotationInterval: internal.Duration{Duration: time.Hour},
+		serializer:       s,
+	}
+	require.NoError(t, f.Connect())
+	require.NoError(t, f.Write(testutil.MockMetrics()))
+	require.NoError(t, f.Close())
+
+	validateFile(path, expNewFile, t)
+	archives, err := filepath.Glob(path + ".*")
+	require.NoError(t, err)
+	require.Len(t, archives, 1)
+	validateFile(archives[0], expNewFile, t)
+}
+
+func TestFileTemplateIdle(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	s, _ := serializers.NewInfluxSerializer()
+	f := File{
+		Files:      []string{filepath.Join(dir, "{{.Tags.host}}.out")},
+		serializer: s,
+	}
+	require.NoError(t, f.Connect())
+	defer f.Close()
+
+	now := time.Now()
+	a, _ := telegraf.NewMetric("cpu", map[string]string{"host": "a"},
+		map[string]interface{}{"value": 1.0}, now)
+	b, _ := telegraf.NewMetric("cpu", map[string]string{"host": "b"},
+		map[string]interface{}{"value": 2.0}, now)
+	pathA := filepath.Join(dir, "a.out")
+
+	require.NoError(t, f.Write([]telegraf.Metric{a}))
+	require.NoError(t, f.Write([]telegraf.Metric{b}))
+	assert.Contains(t, f.partition, pathA)
+
+	f.partition[pathA].written = now.Add(-2 * partitionIdleTimeout)
+	require.NoError(t, f.Write([]telegraf.Metric{b}))
+	assert.NotContains(t, f.partition, pathA)
+}
+
+func TestFileConnectAgain(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	s, _ := serializers.NewInfluxSerializer()
+	f := File{
+		Files: []string{
+			filepath.Join(dir, "metrics.out"),
+			filepath.Join(dir, "{{.Name}}.out"),
+		},
+		serializer: s,
+	}
+	require.NoError(t, f.Connect())
+	require.NoError(t, f.Close())
+	require.NoError(t, f.Connect())
+	require.NoError(t, f.Write(testutil.MockMetrics()))
+	require.NoError(t, f.Close())
+
+	validateFile(filepath.Join(dir, "metrics.out"), expNewFile, t)
+	validateFile(filepath.Join(dir, "test1.out"), expNewFile, t)
+}
+
+func TestFileTemplateMaxOpen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	s, _ := serializers.NewInfluxSerializer()
+	f := File{
+		Files:      []string{filepath.Join(dir, "{{.Tags.id}}.out")},
+		serializer: s,
+	}
+	require.NoError(t, f.Connect())
+	defer f.Close()
+
+	now := time.Now()
+	for n := 0; n <= maxOpenPartitions; n++ {
+		m, _ := telegraf.NewMetric("cpu", map[string]string{"id": strconv.Itoa(n)},
+			map[string]interface{}{"value": 1.0}, now)
+		require.NoError(t, f.Write([]telegraf.Metric{m}))
+		f.partition[filepath.Join(dir, strconv.Itoa(n)+".out")].written = now.Add(time.Duration(n))
+	}
+	assert.Len(t, f.partition, maxOpenPartitions)
+	assert.NotContains(t, f.partition, filepath.Join(dir, "0.out"))
+	assert.Contains(t, f.partition, filepath.Join(dir, "1.out"))
+}
+
+func TestFileTemplateInvalidName(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	s, _ := serializers.NewInfluxSerializer()
+	f := File{
+		Files:      []string{filepath.Join(dir, "{{.Tags.host}}") + "/{{.Tags.file}}"},
+		serializer: s,
+	}
+	require.NoError(t, f.Connect())
+
+	now := time.Unix(0, 0)
+	m1, _ := telegraf.NewMetric("cpu", map[string]string{"host": "a"},
+		map[string]interface{}{"value": 1.0}, now)
+	m2, _ := telegraf.NewMetric("cpu", map[string]string{"host": "a", "file": "cpu.out"},
+		map[string]interface{}{"value": 2.0}, now)
+	require.NoError(t, f.Write([]telegraf.Metric{m1, m2}))
+	require.NoError(t, f.Close())
+
+	validateFile(filepath.Join(dir, "a", "cpu.out"),
+		"cpu,file=cpu.out,host=a value=2 0\n", t)
+}
@@ -0,0 +1,187 @@
+package file
+
+import (
+	"compress/gzip"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// archiveTimeFormat is the suffix of the rotated files, sorting in the order
+// they were rotated.
+const archiveTimeFormat = "20060102T150405.000000000"
+
+// rotatingFile appends to a file which is rotated when it would grow beyond
+// maxSize bytes, or when it is older than interval. The age of a file which
+// already holds data when opened is counted from its last modification. Zero
+// values disable the rotation. Rotated files are renamed with the time of
+// their rotation, gzipped if compress is set, and only the newest
+// maxArchives of them are kept, unless maxArchives is zero.
+type rotatingFile struct {
+	path        string
+	interval    time.Duration
+	maxSize     int64
+	maxArchives int
+	compress    bool
+
+	file   *os.File
+	size   int64
+	opened time.Time
+	// time of the last write
+	written time.Time
+}
+
+func openRotatingFile(
+	path string,
+	interval time.Duration,
+	maxSize int64,
+	maxArchives int,
+	compress bool,
+) (*rotatingFile, error) {
+	r := &rotatingFile{
+		path:        path,
+		interval:    interval,
+		maxSize:     maxSize,
+		maxArchives: maxArchives,
+		compress:    compress,
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rotatingFile) open() error {
+	if dir := filepath.Dir(r.path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	file, err := os.OpenFile(r.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0666)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	r.file = file
+	r.size = info.Size()
+	r.opened = time.Now()
+	if r.size > 0 {
+		// keep the files from never rotating when telegraf restarts more
+		// often than the interval
+		r.opened = info.ModTime()
+	}
+	return nil
+}
+
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	if r.needsRotation(len(p)) {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	r.written = time.Now()
+	return n, err
+}
+
+func (r *rotatingFile) needsRotation(n int) bool {
+	if r.size == 0 {
+		return false
+	}
+	if r.maxSize > 0 && r.size+int64(n) > r.maxSize {
+		return true
+	}
+	return r.interval > 0 && time.Since(r.opened) >= r.interval
+}
+
+func (r *rotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+
+	archive := r.path + "." + time.Now().Format(archiveTimeFormat)
+	if err := os.Rename(r.path, archive); err != nil {
+		return err
+	}
+	if r.compress {
+		if err := compressFile(archive); err != nil {
+			log.Printf("E! file: unable to compress %s: %s\n", archive, err)
+		}
+	}
+	if err := r.removeArchives(); err != nil {
+		log.Printf("E! file: unable to remove the archives of %s: %s\n", r.path, err)
+	}
+
+	return r.open()
+}
+
+// removeArchives removes the oldest archives beyond maxArchives.
+func (r *rotatingFile) removeArchives() error {
+	if r.maxArchives <= 0 {
+		return nil
+	}
+
+	matches, err := filepath.Glob(r.path + ".*")
+	if err != nil {
+		return err
+	}
+	var archives []string
+	for _, m := range matches {
+		suffix := strings.TrimSuffix(m[len(r.path)+1:], ".gz")
+		if _, err := time.Parse(archiveTimeFormat, suffix); err == nil {
+			archives = append(archives, m)
+		}
+	}
+	sort.Strings(archives)
+
+	for len(archives) > r.maxArchives {
+		if err := os.Remove(archives[0]); err != nil {
+			return err
+		}
+		archives = archives[1:]
+	}
+	return nil
+}
+
+func (r *rotatingFile) Close() error {
+	return r.file.Close()
+}
+
+// compressFile replaces a file by its gzipped copy, suffixed with .gz.
+func compressFile(path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0666)
+	if err != nil {
+		return err
+	}
+	w := gzip.NewWriter(out)
+	if _, err := io.Copy(w, in); err != nil {
+		out.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := w.Close(); err != nil {
+		out.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(path + ".gz")
+		return err
+	}
+	return os.Remove(path)
+}
@@ -15,11 +15,12 @@ import (
 
 type Graphite struct {
 	// URL is only for backwards compatability
-	Servers  []string
-	Prefix   string
-	Template string
-	Timeout  int
-	conns    []net.Conn
+	Servers    []string
+	Prefix     string
+	Template   string
+	TagSupport bool `toml:"graphite_tag_support"`
+	Timeout    int
+	conns      []net.Conn
 }
 
 var sampleConfig = `
@@ -32,6 +33,9 @@ var sampleConfig = `
   ## Graphite output template
   ## see https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_OUTPUT.md
   template = "host.tags.measurement.field"
+  ## Write tagged series ("cpu.usage_idle;host=a 91.5 1479822000") instead of
+  ## using the template, requires graphite 1.1 or later.
+  graphite_tag_support = false
   ## timeout in seconds for the write connection to graphite
   timeout = 2
 `
@@ -77,7 +81,7 @@ func (g *Graphite) Description() string {
 func (g *Graphite) Write(metrics []telegraf.Metric) error {
 	// Prepare data
 	var bp []string
-	s, err := serializers.NewGraphiteSerializer(g.Prefix, g.Template)
+	s, err := serializers.NewGraphiteSerializer(g.Prefix, g.Template, g.TagSupport)
 	if err != nil {
 		return err
 	}
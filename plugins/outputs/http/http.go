@@ -0,0 +1,218 @@
+package http
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/outputs"
+	"github.com/influxdata/telegraf/plugins/serializers"
+)
+
+const (
+	defaultMethod      = "POST"
+	defaultContentType = "text/plain; charset=utf-8"
+)
+
+var sampleConfig = `
+  ## URL to send the metrics to
+  url = "http://127.0.0.1:8080/telegraf"
+
+  ## HTTP method, one of: "POST", "PUT" or "PATCH"
+  # method = "POST"
+
+  ## Timeout of the HTTP requests
+  # timeout = "5s"
+
+  ## HTTP basic authentication
+  # username = "telegraf"
+  # password = "metricsmetricsmetricsmetrics"
+
+  ## Bearer token sent in the Authorization header
+  # bearer_token = ""
+
+  ## Compress the body of the requests, either "identity" or "gzip"
+  # content_encoding = "identity"
+
+  ## Optional SSL Config
+  # ssl_ca = "/etc/telegraf/ca.pem"
+  # ssl_cert = "/etc/telegraf/cert.pem"
+  # ssl_key = "/etc/telegraf/key.pem"
+  ## Use SSL but skip chain & host verification
+  # insecure_skip_verify = false
+
+  ## Data format to output.
+  ## Each data format has it's own unique set of configuration options, read
+  ## more about them here:
+  ## https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_OUTPUT.md
+  data_format = "influx"
+
+  ## Additional HTTP headers
+  # [outputs.http.headers]
+  #   Content-Type = "text/plain; charset=utf-8"
+`
+
+type HTTP struct {
+	URL             string `toml:"url"`
+	Method          string
+	Timeout         internal.Duration
+	Username        string
+	Password        string
+	BearerToken     string
+	ContentEncoding string
+	Headers         map[string]string
+
+	// Path to CA file
+	SSLCA string `toml:"ssl_ca"`
+	// Path to host cert file
+	SSLCert string `toml:"ssl_cert"`
+	// Path to cert key file
+	SSLKey string `toml:"ssl_key"`
+	// Use SSL but skip chain & host verification
+	InsecureSkipVerify bool
+
+	client     *http.Client
+	serializer serializers.Serializer
+}
+
+func (h *HTTP) SetSerializer(serializer serializers.Serializer) {
+	h.serializer = serializer
+}
+
+func (h *HTTP) Connect() error {
+	if h.URL == "" {
+		return fmt.Errorf("http: url is required")
+	}
+	if _, err := url.Parse(h.URL); err != nil {
+		return fmt.Errorf("http: invalid url %s: %s", h.URL, err)
+	}
+
+	if h.Method == "" {
+		h.Method = defaultMethod
+	}
+	h.Method = strings.ToUpper(h.Method)
+	switch h.Method {
+	case "POST", "PUT", "PATCH":
+	default:
+		return fmt.Errorf("http: invalid method %s", h.Method)
+	}
+
+	switch h.ContentEncoding {
+	case "", "identity", "gzip":
+	default:
+		return fmt.Errorf("http: invalid content encoding %s", h.ContentEncoding)
+	}
+
+	if h.Username != "" && h.BearerToken != "" {
+		return fmt.Errorf("http: basic authentication and bearer token are exclusive")
+	}
+
+	tlsCfg, err := internal.GetTLSConfig(
+		h.SSLCert, h.SSLKey, h.SSLCA, h.InsecureSkipVerify)
+	if err != nil {
+		return err
+	}
+
+	h.client = internal.NewHTTPClient(h.Timeout.Duration, tlsCfg)
+	return nil
+}
+
+func (h *HTTP) Close() error {
+	return nil
+}
+
+func (h *HTTP) Description() string {
+	return "A plugin that can transmit metrics over HTTP"
+}
+
+func (h *HTTP) SampleConfig() string {
+	return sampleConfig
+}
+
+// Write sends the metrics in a single request. Failed requests are retried
+// if internal.RetriableStatus holds for the response, and dropped otherwise.
+func (h *HTTP) Write(metrics []telegraf.Metric) error {
+	if len(metrics) == 0 {
+		return nil
+	}
+
+	body, err := serializers.NewBatchSerializer(h.serializer).SerializeBatch(metrics)
+	if err != nil {
+		return err
+	}
+
+	var reader io.Reader = bytes.NewReader(body)
+	if h.ContentEncoding == "gzip" {
+		reader, err = compress(body)
+		if err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest(h.Method, h.URL, reader)
+	if err != nil {
+		return fmt.Errorf("http: unable to create request: %s", err)
+	}
+	req.Header.Set("User-Agent", "Telegraf")
+	req.Header.Set("Content-Type", defaultContentType)
+	if h.ContentEncoding == "gzip" {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	if h.Username != "" || h.Password != "" {
+		req.SetBasicAuth(h.Username, h.Password)
+	}
+	if h.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+h.BearerToken)
+	}
+	for k, v := range h.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("http: error sending metrics to %s: %s", h.URL, err)
+	}
+	defer resp.Body.Close()
+	msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	if internal.RetriableStatus(resp.StatusCode) {
+		return fmt.Errorf("http: %s responded %s: %s",
+			h.URL, resp.Status, bytes.TrimSpace(msg))
+	}
+	log.Printf("E! http: %s responded %s, dropping %d metrics: %s\n",
+		h.URL, resp.Status, len(metrics), bytes.TrimSpace(msg))
+	return nil
+}
+
+func compress(body []byte) (io.Reader, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(body); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return &buf, nil
+}
+
+func init() {
+	outputs.Add("http", func() telegraf.Output {
+		return &HTTP{
+			Timeout: internal.Duration{Duration: 5 * time.Second},
+			Method:  defaultMethod,
+		}
+	})
+}
@@ -0,0 +1,131 @@
+package http
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/serializers"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+const expBody = "test1,tag1=value1 value=1 1257894000000000000\n" +
+	"test2,tag1=value1 value=1 1257894000000000000\n"
+
+func testMetrics() []telegraf.Metric {
+	return []telegraf.Metric{
+		testutil.TestMetric(1.0),
+		testutil.TestMetric(1.0, "test2"),
+	}
+}
+
+func newHTTP(url string) *HTTP {
+	s, _ := serializers.NewInfluxSerializer()
+	h := &HTTP{URL: url}
+	h.SetSerializer(s)
+	return h
+}
+
+func TestWrite(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "PUT", r.Method)
+		assert.Equal(t, "text/plain; charset=utf-8", r.Header.Get("Content-Type"))
+		assert.Equal(t, "bar", r.Header.Get("X-Foo"))
+		username, password, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "telegraf", username)
+		assert.Equal(t, "secret", password)
+
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		assert.Equal(t, expBody, string(body))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	h := newHTTP(ts.URL)
+	h.Method = "put"
+	h.Username = "telegraf"
+	h.Password = "secret"
+	h.Headers = map[string]string{"X-Foo": "bar"}
+	require.NoError(t, h.Connect())
+	require.NoError(t, h.Write(testMetrics()))
+}
+
+func TestWriteGzipBearerToken(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "Bearer abc", r.Header.Get("Authorization"))
+		assert.Equal(t, "gzip", r.Header.Get("Content-Encoding"))
+
+		gr, err := gzip.NewReader(r.Body)
+		require.NoError(t, err)
+		body, err := ioutil.ReadAll(gr)
+		require.NoError(t, err)
+		assert.Equal(t, expBody, string(body))
+	}))
+	defer ts.Close()
+
+	h := newHTTP(ts.URL)
+	h.BearerToken = "abc"
+	h.ContentEncoding = "gzip"
+	require.NoError(t, h.Connect())
+	require.NoError(t, h.Write(testMetrics()))
+}
+
+func TestWriteStatusCodes(t *testing.T) {
+	var status int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer ts.Close()
+
+	h := newHTTP(ts.URL)
+	require.NoError(t, h.Connect())
+
+	tests := []struct {
+		status    int
+		retriable bool
+	}{
+		{http.StatusOK, false},
+		{http.StatusBadRequest, false},
+		{http.StatusUnauthorized, false},
+		{http.StatusRequestTimeout, true},
+		{http.StatusTooManyRequests, true},
+		{http.StatusInternalServerError, true},
+		{http.StatusServiceUnavailable, true},
+	}
+	for _, tt := range tests {
+		status = tt.status
+		err := h.Write(testMetrics())
+		if tt.retriable {
+			assert.Error(t, err, "status %d", tt.status)
+		} else {
+			assert.NoError(t, err, "status %d", tt.status)
+		}
+	}
+}
+
+func TestConnectInvalidOptions(t *testing.T) {
+	h := newHTTP("")
+	assert.Error(t, h.Connect())
+
+	h = newHTTP("http://localhost")
+	h.Method = "GET"
+	assert.Error(t, h.Connect())
+
+	h = newHTTP("http://localhost")
+	h.ContentEncoding = "br"
+	assert.Error(t, h.Connect())
+
+	h = newHTTP("http://localhost")
+	h.Username = "telegraf"
+	h.BearerToken = "abc"
+	assert.Error(t, h.Connect())
+}
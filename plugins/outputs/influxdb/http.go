@@ -0,0 +1,230 @@
+package influxdb
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+)
+
+// httpClient writes line protocol to the HTTP API of InfluxDB.
+type httpClient struct {
+	url              string
+	username         string
+	password         string
+	userAgent        string
+	writeConsistency string
+	contentEncoding  string
+
+	client *http.Client
+}
+
+func newHTTPClient(
+	addr, username, password, userAgent, writeConsistency, contentEncoding string,
+	timeout time.Duration,
+	tlsCfg *tls.Config,
+) (*httpClient, error) {
+	u, err := url.Parse(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url %s: %s", addr, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported protocol scheme %q in %s", u.Scheme, addr)
+	}
+	switch contentEncoding {
+	case "", "identity", "gzip":
+	default:
+		return nil, fmt.Errorf("invalid content encoding %s", contentEncoding)
+	}
+	if userAgent == "" {
+		userAgent = "telegraf"
+	}
+
+	return &httpClient{
+		url:              strings.TrimRight(addr, "/"),
+		username:         username,
+		password:         password,
+		userAgent:        userAgent,
+		writeConsistency: writeConsistency,
+		contentEncoding:  contentEncoding,
+		client:           internal.NewHTTPClient(timeout, tlsCfg),
+	}, nil
+}
+
+func (c *httpClient) CreateDatabase(database string) error {
+	params := url.Values{}
+	params.Set("q", fmt.Sprintf(`CREATE DATABASE "%s"`,
+		strings.Replace(database, `"`, `\"`, -1)))
+
+	req, err := c.newRequest("POST", c.url+"/query?"+params.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Error   string
+		Results []struct {
+			Error string
+		}
+	}
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+	json.Unmarshal(body, &result)
+	if result.Error == "" && len(result.Results) > 0 {
+		result.Error = result.Results[0].Error
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		if result.Error == "" {
+			result.Error = strings.TrimSpace(string(body))
+		}
+		return fmt.Errorf("%s responded %s: %s", c.url, resp.Status, result.Error)
+	}
+	if result.Error != "" {
+		return fmt.Errorf("%s", result.Error)
+	}
+	return nil
+}
+
+// APIError is the error of a request rejected by InfluxDB.
+type APIError struct {
+	URL        string
+	Status     string
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s responded %s: %s", e.URL, e.Status, e.Message)
+}
+
+// Write writes the metrics in a single request. Batches too large for the
+// server, rejected with 413 Request Entity Too Large, are split in halves
+// which are written in turn; a single metric too large is dropped. If only
+// one of the halves is written, the other is dropped, as the error would
+// make the output write the successful half again.
+func (c *httpClient) Write(database, retentionPolicy string, metrics []telegraf.Metric) error {
+	if len(metrics) == 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	for _, metric := range metrics {
+		buf.WriteString(metric.String())
+		buf.WriteByte('\n')
+	}
+
+	var body io.Reader = &buf
+	if c.contentEncoding == "gzip" {
+		var err error
+		body, err = compress(buf.Bytes())
+		if err != nil {
+			return err
+		}
+	}
+
+	params := url.Values{}
+	params.Set("db", database)
+	if retentionPolicy != "" {
+		params.Set("rp", retentionPolicy)
+	}
+	if c.writeConsistency != "" {
+		params.Set("consistency", c.writeConsistency)
+	}
+
+	req, err := c.newRequest("POST", c.url+"/write?"+params.Encode(), body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if c.contentEncoding == "gzip" {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	if resp.StatusCode == http.StatusRequestEntityTooLarge {
+		if len(metrics) == 1 {
+			log.Printf("E! InfluxDB Output Error: metric too large for %s, dropping it\n", c.url)
+			return nil
+		}
+		half := len(metrics) / 2
+		errFirst := c.Write(database, retentionPolicy, metrics[:half])
+		errSecond := c.Write(database, retentionPolicy, metrics[half:])
+		switch {
+		case errFirst != nil && errSecond != nil:
+			return errFirst
+		case errFirst != nil:
+			log.Printf("E! InfluxDB Output Error: %s, dropping %d metrics\n", errFirst, half)
+		case errSecond != nil:
+			log.Printf("E! InfluxDB Output Error: %s, dropping %d metrics\n",
+				errSecond, len(metrics)-half)
+		}
+		return nil
+	}
+
+	var result struct {
+		Error string
+	}
+	if json.Unmarshal(msg, &result) != nil || result.Error == "" {
+		result.Error = strings.TrimSpace(string(msg))
+	}
+	return &APIError{
+		URL:        c.url,
+		Status:     resp.Status,
+		StatusCode: resp.StatusCode,
+		Message:    result.Error,
+	}
+}
+
+func (c *httpClient) Close() error {
+	return nil
+}
+
+func (c *httpClient) newRequest(method, u string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+	if c.username != "" || c.password != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+	return req, nil
+}
+
+func compress(body []byte) (io.Reader, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(body); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return &buf, nil
+}
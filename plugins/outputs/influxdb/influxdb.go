@@ -16,6 +16,13 @@ import (
 	"github.com/influxdata/influxdb/client/v2"
 )
 
+// Client writes batches of metrics to InfluxDB.
+type Client interface {
+	Write(database, retentionPolicy string, metrics []telegraf.Metric) error
+	CreateDatabase(database string) error
+	Close() error
+}
+
 type InfluxDB struct {
 	// URL is only for backwards compatability
 	URL              string
@@ -27,7 +34,13 @@ type InfluxDB struct {
 	RetentionPolicy  string
 	WriteConsistency string
 	Timeout          internal.Duration
-	UDPPayload       int `toml:"udp_payload"`
+	UDPPayload       int    `toml:"udp_payload"`
+	ContentEncoding  string `toml:"content_encoding"`
+
+	// Tags naming the database and the retention policy of each metric,
+	// removed from the metrics written.
+	DatabaseTag        string `toml:"database_tag"`
+	RetentionPolicyTag string `toml:"retention_policy_tag"`
 
 	// Path to CA file
 	SSLCA string `toml:"ssl_ca"`
@@ -41,7 +54,7 @@ type InfluxDB struct {
 	// Precision is only here for legacy support. It will be ignored.
 	Precision string
 
-	conns []client.Client
+	conns []Client
 }
 
 var sampleConfig = `
@@ -53,8 +66,17 @@ var sampleConfig = `
   ## The target database for metrics (telegraf will create it if not exists).
   database = "telegraf" # required
 
+  ## Name of a tag whose value is the database of each metric, instead of
+  ## database. The tag is not written; metrics without it are written to
+  ## database. Missing databases are created, whatever the value of the tag,
+  ## so only use tags set by trusted inputs.
+  # database_tag = ""
+
   ## Retention policy to write to. Empty string writes to the default rp.
   retention_policy = ""
+  ## Name of a tag whose value is the retention policy of each metric,
+  ## instead of retention_policy. The tag is not written.
+  # retention_policy_tag = ""
   ## Write consistency (clusters only), can be: "any", "one", "quorum", "all"
   write_consistency = "any"
 
@@ -68,6 +90,9 @@ var sampleConfig = `
   ## Set UDP payload size, defaults to InfluxDB UDP Client default (512 bytes)
   # udp_payload = 512
 
+  ## Compress the body of the HTTP writes, either "identity" or "gzip"
+  # content_encoding = "identity"
+
   ## Optional SSL Config
   # ssl_ca = "/etc/telegraf/ca.pem"
   # ssl_cert = "/etc/telegraf/cert.pem"
@@ -94,7 +119,7 @@ func (i *InfluxDB) Connect() error {
 		return err
 	}
 
-	var conns []client.Client
+	var conns []Client
 	for _, u := range urls {
 		switch {
 		case strings.HasPrefix(u, "udp"):
@@ -106,29 +131,20 @@ func (i *InfluxDB) Connect() error {
 			if i.UDPPayload == 0 {
 				i.UDPPayload = client.UDPPayloadSize
 			}
-			c, err := client.NewUDPClient(client.UDPConfig{
-				Addr:        parsed_url.Host,
-				PayloadSize: i.UDPPayload,
-			})
+			c, err := newUDPClient(parsed_url.Host, i.UDPPayload)
 			if err != nil {
 				return err
 			}
 			conns = append(conns, c)
 		default:
 			// If URL doesn't start with "udp", assume HTTP client
-			c, err := client.NewHTTPClient(client.HTTPConfig{
-				Addr:      u,
-				Username:  i.Username,
-				Password:  i.Password,
-				UserAgent: i.UserAgent,
-				Timeout:   i.Timeout.Duration,
-				TLSConfig: tlsCfg,
-			})
+			c, err := newHTTPClient(u, i.Username, i.Password, i.UserAgent,
+				i.WriteConsistency, i.ContentEncoding, i.Timeout.Duration, tlsCfg)
 			if err != nil {
 				return err
 			}
 
-			err = createDatabase(c, i.Database)
+			err = c.CreateDatabase(i.Database)
 			if err != nil {
 				log.Println("E! Database creation failed: " + err.Error())
 				continue
@@ -143,14 +159,6 @@ func (i *InfluxDB) Connect() error {
 	return nil
 }
 
-func createDatabase(c client.Client, database string) error {
-	// Create Database if it doesn't exist
-	_, err := c.Query(client.Query{
-		Command: fmt.Sprintf("CREATE DATABASE \"%s\"", database),
-	})
-	return err
-}
-
 func (i *InfluxDB) Close() error {
 	var errS string
 	for j, _ := range i.conns {
@@ -173,7 +181,15 @@ func (i *InfluxDB) Description() string {
 }
 
 // Choose a random server in the cluster to write to until a successful write
-// occurs, logging each unsuccessful. If all servers fail, return error.
+// occurs, logging each unsuccessful. Metrics are written in a batch per
+// database and retention policy.
+//
+// Batches rejected by all the servers with a permanent error, such as a
+// missing retention policy, are logged and dropped. Batches failing with
+// errors which may succeed later are returned as an error so that the metrics
+// are written again, unless other batches were already written: as the
+// output writes all the metrics again on errors, the failed batches are then
+// logged and dropped too.
 func (i *InfluxDB) Write(metrics []telegraf.Metric) error {
 	if len(i.conns) == 0 {
 		err := i.Connect()
@@ -181,43 +197,134 @@ func (i *InfluxDB) Write(metrics []telegraf.Metric) error {
 			return err
 		}
 	}
-	bp, err := client.NewBatchPoints(client.BatchPointsConfig{
-		Database:         i.Database,
-		RetentionPolicy:  i.RetentionPolicy,
-		WriteConsistency: i.WriteConsistency,
-	})
+
+	batches, err := i.batches(metrics)
 	if err != nil {
 		return err
 	}
 
-	for _, metric := range metrics {
-		bp.AddPoint(metric.Point())
+	written := false
+	var failed []*batch
+	var retryErr error
+	for _, b := range batches {
+		err := i.writeBatch(b)
+		switch {
+		case err == nil:
+			written = true
+		case !retriable(err):
+			log.Printf("E! InfluxDB Output Error: %s, dropping %d metrics of database %s\n",
+				err, len(b.metrics), b.database)
+		default:
+			failed = append(failed, b)
+			if retryErr == nil {
+				retryErr = err
+			}
+		}
 	}
 
-	// This will get set to nil if a successful write occurs
-	err = errors.New("Could not write to any InfluxDB server in cluster")
+	if retryErr == nil || !written {
+		return retryErr
+	}
+	for _, b := range failed {
+		log.Printf("E! InfluxDB Output Error: unable to write %d metrics of database %s, dropping them as other metrics were written\n",
+			len(b.metrics), b.database)
+	}
+	return nil
+}
 
+// writeBatch writes the batch to a random server, trying the other servers
+// on errors. The returned error is retriable if any server failed with a
+// retriable error.
+func (i *InfluxDB) writeBatch(b *batch) error {
+	var err error
 	p := rand.Perm(len(i.conns))
 	for _, n := range p {
-		if e := i.conns[n].Write(bp); e != nil {
-			// Log write failure
-			log.Printf("E! InfluxDB Output Error: %s", e)
-			// If the database was not found, try to recreate it
-			if strings.Contains(e.Error(), "database not found") {
-				if errc := createDatabase(i.conns[n], i.Database); errc != nil {
-					log.Printf("E! Error: Database %s not found and failed to recreate\n",
-						i.Database)
-				}
+		e := i.conns[n].Write(b.database, b.retentionPolicy, b.metrics)
+		// If the database was not found, try to recreate it
+		if e != nil && strings.Contains(e.Error(), "database not found") {
+			if errc := i.conns[n].CreateDatabase(b.database); errc != nil {
+				log.Printf("E! Error: Database %s not found and failed to recreate\n",
+					b.database)
+			} else {
+				e = i.conns[n].Write(b.database, b.retentionPolicy, b.metrics)
 			}
-		} else {
-			err = nil
-			break
 		}
-	}
+		if e == nil {
+			return nil
+		}
 
+		// Log write failure
+		log.Printf("E! InfluxDB Output Error: %s", e)
+		if err == nil || retriable(e) {
+			err = e
+		}
+	}
+	if err == nil {
+		err = errors.New("Could not write to any InfluxDB server in cluster")
+	}
 	return err
 }
 
+// retriable reports whether a failed write may succeed later. Only the
+// responses of InfluxDB can tell that it never will.
+func retriable(err error) bool {
+	if e, ok := err.(*APIError); ok {
+		return internal.RetriableStatus(e.StatusCode)
+	}
+	return true
+}
+
+// batch holds the metrics written to a database and retention policy.
+type batch struct {
+	database        string
+	retentionPolicy string
+	metrics         []telegraf.Metric
+}
+
+// batches groups the metrics by their database and retention policy, in the
+// order they are first seen, removing the tags naming them.
+func (i *InfluxDB) batches(metrics []telegraf.Metric) ([]*batch, error) {
+	if i.DatabaseTag == "" && i.RetentionPolicyTag == "" {
+		return []*batch{{i.Database, i.RetentionPolicy, metrics}}, nil
+	}
+
+	var batches []*batch
+	index := make(map[[2]string]*batch)
+	for _, metric := range metrics {
+		tags := metric.Tags()
+		database, retentionPolicy := i.Database, i.RetentionPolicy
+		routed := false
+		if v, ok := tags[i.DatabaseTag]; ok && i.DatabaseTag != "" {
+			database = v
+			delete(tags, i.DatabaseTag)
+			routed = true
+		}
+		if v, ok := tags[i.RetentionPolicyTag]; ok && i.RetentionPolicyTag != "" {
+			retentionPolicy = v
+			delete(tags, i.RetentionPolicyTag)
+			routed = true
+		}
+
+		if routed {
+			m, err := telegraf.NewTypedMetric(metric.Name(), tags, metric.Fields(), metric.Time(), metric.Type())
+			if err != nil {
+				return nil, err
+			}
+			metric = m
+		}
+
+		key := [2]string{database, retentionPolicy}
+		b, ok := index[key]
+		if !ok {
+			b = &batch{database: database, retentionPolicy: retentionPolicy}
+			index[key] = b
+			batches = append(batches, b)
+		}
+		b.metrics = append(b.metrics, metric)
+	}
+	return batches, nil
+}
+
 func init() {
 	outputs.Add("influxdb", func() telegraf.Output {
 		return &InfluxDB{
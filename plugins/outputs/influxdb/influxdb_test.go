@@ -1,13 +1,20 @@
 package influxdb
 
 import (
+	"compress/gzip"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/testutil"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -39,3 +46,208 @@ func TestHTTPInflux(t *testing.T) {
 	err = i.Write(testutil.MockMetrics())
 	require.NoError(t, err)
 }
+
+func TestHTTPInfluxGzip(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/write" {
+			assert.Equal(t, "gzip", r.Header.Get("Content-Encoding"))
+			gz, err := gzip.NewReader(r.Body)
+			require.NoError(t, err)
+			body, err := ioutil.ReadAll(gz)
+			require.NoError(t, err)
+			assert.Equal(t, "test1,tag1=value1 value=1 1257894000000000000\n", string(body))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	i := InfluxDB{
+		URLs:            []string{ts.URL},
+		ContentEncoding: "gzip",
+	}
+
+	require.NoError(t, i.Connect())
+	require.NoError(t, i.Write(testutil.MockMetrics()))
+}
+
+func TestHTTPInfluxDatabaseTag(t *testing.T) {
+	var mu sync.Mutex
+	writes := make(map[string]string)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/write" {
+			body, err := ioutil.ReadAll(r.Body)
+			require.NoError(t, err)
+			q := r.URL.Query()
+			mu.Lock()
+			writes[q.Get("db")+"/"+q.Get("rp")] = string(body)
+			mu.Unlock()
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	i := InfluxDB{
+		URLs:               []string{ts.URL},
+		Database:           "telegraf",
+		DatabaseTag:        "db",
+		RetentionPolicyTag: "rp",
+	}
+
+	now := time.Unix(0, 0)
+	m1, _ := telegraf.NewMetric("cpu", map[string]string{"db": "foo", "host": "a"},
+		map[string]interface{}{"value": 1.0}, now)
+	m2, _ := telegraf.NewMetric("cpu", map[string]string{"db": "foo", "rp": "short"},
+		map[string]interface{}{"value": 2.0}, now)
+	m3, _ := telegraf.NewMetric("cpu", map[string]string{"host": "b"},
+		map[string]interface{}{"value": 3.0}, now)
+
+	require.NoError(t, i.Connect())
+	require.NoError(t, i.Write([]telegraf.Metric{m1, m2, m3}))
+	assert.Equal(t, map[string]string{
+		"foo/":      "cpu,host=a value=1 0\n",
+		"foo/short": "cpu value=2 0\n",
+		"telegraf/": "cpu,host=b value=3 0\n",
+	}, writes)
+}
+
+func TestHTTPInfluxSplitTooLarge(t *testing.T) {
+	var lines []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/write" {
+			body, err := ioutil.ReadAll(r.Body)
+			require.NoError(t, err)
+			if strings.Count(string(body), "\n") > 2 {
+				w.WriteHeader(http.StatusRequestEntityTooLarge)
+				return
+			}
+			lines = append(lines, strings.Split(strings.TrimSpace(string(body)), "\n")...)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	i := InfluxDB{
+		URLs: []string{ts.URL},
+	}
+
+	var metrics []telegraf.Metric
+	for n := 0; n < 5; n++ {
+		metrics = append(metrics, testutil.TestMetric(n))
+	}
+
+	require.NoError(t, i.Connect())
+	require.NoError(t, i.Write(metrics))
+	require.Len(t, lines, 5)
+	for n, line := range lines {
+		assert.Equal(t, metrics[n].String(), line)
+	}
+}
+
+func TestHTTPInfluxError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/write" {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprintln(w, `{"error":"timeout"}`)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	i := InfluxDB{
+		URLs: []string{ts.URL},
+	}
+
+	require.NoError(t, i.Connect())
+	require.Error(t, i.Write(testutil.MockMetrics()))
+}
+
+func TestHTTPInfluxPartialWrite(t *testing.T) {
+	var mu sync.Mutex
+	writes := make(map[string]string)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/write" {
+			q := r.URL.Query()
+			switch {
+			case q.Get("rp") == "missing":
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprintln(w, `{"error":"retention policy not found: missing"}`)
+				return
+			case q.Get("db") == "down":
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			body, err := ioutil.ReadAll(r.Body)
+			require.NoError(t, err)
+			mu.Lock()
+			writes[q.Get("db")+"/"+q.Get("rp")] = string(body)
+			mu.Unlock()
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	i := InfluxDB{
+		URLs:               []string{ts.URL},
+		Database:           "telegraf",
+		DatabaseTag:        "db",
+		RetentionPolicyTag: "rp",
+	}
+	require.NoError(t, i.Connect())
+
+	now := time.Unix(0, 0)
+	missing, _ := telegraf.NewMetric("cpu", map[string]string{"rp": "missing"},
+		map[string]interface{}{"value": 1.0}, now)
+	down, _ := telegraf.NewMetric("cpu", map[string]string{"db": "down"},
+		map[string]interface{}{"value": 2.0}, now)
+	ok, _ := telegraf.NewMetric("cpu", nil,
+		map[string]interface{}{"value": 3.0}, now)
+
+	// A missing retention policy never succeeds, its metrics are dropped.
+	require.NoError(t, i.Write([]telegraf.Metric{missing}))
+
+	// Failures which may succeed later are retried, unless other metrics
+	// were written.
+	require.Error(t, i.Write([]telegraf.Metric{missing, down}))
+	require.NoError(t, i.Write([]telegraf.Metric{missing, down, ok}))
+	assert.Equal(t, map[string]string{
+		"telegraf/": "cpu value=3 0\n",
+	}, writes)
+}
+
+func TestHTTPInfluxSplitPartialWrite(t *testing.T) {
+	var lines []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/write" {
+			body, err := ioutil.ReadAll(r.Body)
+			require.NoError(t, err)
+			switch {
+			case strings.Count(string(body), "\n") > 2:
+				w.WriteHeader(http.StatusRequestEntityTooLarge)
+				return
+			case strings.Contains(string(body), "value=4i"):
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			lines = append(lines, strings.Split(strings.TrimSpace(string(body)), "\n")...)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	i := InfluxDB{
+		URLs: []string{ts.URL},
+	}
+
+	var metrics []telegraf.Metric
+	for n := 0; n < 5; n++ {
+		metrics = append(metrics, testutil.TestMetric(n))
+	}
+
+	require.NoError(t, i.Connect())
+	require.NoError(t, i.Write(metrics))
+	require.Len(t, lines, 3)
+	for n, line := range lines {
+		assert.Equal(t, metrics[n].String(), line)
+	}
+}
@@ -0,0 +1,46 @@
+package influxdb
+
+import (
+	"github.com/influxdata/influxdb/client/v2"
+
+	"github.com/influxdata/telegraf"
+)
+
+// udpClient writes to the UDP service of InfluxDB, which writes to the
+// database of its configuration: databases are ignored and can't be created.
+type udpClient struct {
+	conn client.Client
+}
+
+func newUDPClient(addr string, payloadSize int) (*udpClient, error) {
+	conn, err := client.NewUDPClient(client.UDPConfig{
+		Addr:        addr,
+		PayloadSize: payloadSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &udpClient{conn: conn}, nil
+}
+
+func (c *udpClient) CreateDatabase(database string) error {
+	return nil
+}
+
+func (c *udpClient) Write(database, retentionPolicy string, metrics []telegraf.Metric) error {
+	bp, err := client.NewBatchPoints(client.BatchPointsConfig{
+		Database:        database,
+		RetentionPolicy: retentionPolicy,
+	})
+	if err != nil {
+		return err
+	}
+	for _, metric := range metrics {
+		bp.AddPoint(metric.Point())
+	}
+	return c.conn.Write(bp)
+}
+
+func (c *udpClient) Close() error {
+	return c.conn.Close()
+}
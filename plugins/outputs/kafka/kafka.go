@@ -25,6 +25,8 @@ type Kafka struct {
 	RequiredAcks int
 	// MaxRetry Tag
 	MaxRetry int
+	// Batch sends the metrics of a write as a single message per routing key
+	Batch bool
 
 	// Legacy SSL config options
 	// TLS client certificate
@@ -85,6 +87,11 @@ var sampleConfig = `
   ##  The total number of times to retry sending a message
   max_retry = 3
 
+  ## Send all metrics of a write in a single message per routing key, instead
+  ## of a message per metric. Combine with data formats supporting batches,
+  ## such as json with json_array = true.
+  # batch = false
+
   ## Optional SSL Config
   # ssl_ca = "/etc/telegraf/ca.pem"
   # ssl_cert = "/etc/telegraf/cert.pem"
@@ -153,6 +160,10 @@ func (k *Kafka) Write(metrics []telegraf.Metric) error {
 		return nil
 	}
 
+	if k.Batch {
+		return k.writeBatch(metrics)
+	}
+
 	for _, metric := range metrics {
 		values, err := k.serializer.Serialize(metric)
 		if err != nil {
@@ -179,6 +190,41 @@ func (k *Kafka) Write(metrics []telegraf.Metric) error {
 	return nil
 }
 
+// writeBatch sends a message per routing key, holding all metrics of the
+// key.
+func (k *Kafka) writeBatch(metrics []telegraf.Metric) error {
+	var keys []string
+	batches := make(map[string][]telegraf.Metric)
+	for _, metric := range metrics {
+		key := metric.Tags()[k.RoutingTag]
+		if _, ok := batches[key]; !ok {
+			keys = append(keys, key)
+		}
+		batches[key] = append(batches[key], metric)
+	}
+
+	serializer := serializers.NewBatchSerializer(k.serializer)
+	for _, key := range keys {
+		value, err := serializer.SerializeBatch(batches[key])
+		if err != nil {
+			return err
+		}
+
+		m := &sarama.ProducerMessage{
+			Topic: k.Topic,
+			Value: sarama.ByteEncoder(value),
+		}
+		if key != "" {
+			m.Key = sarama.StringEncoder(key)
+		}
+
+		if _, _, err := k.producer.SendMessage(m); err != nil {
+			return fmt.Errorf("FAILED to send kafka message: %s\n", err)
+		}
+	}
+	return nil
+}
+
 func init() {
 	outputs.Add("kafka", func() telegraf.Output {
 		return &Kafka{
@@ -32,6 +32,11 @@ var sampleConfig = `
   ## Use SSL but skip chain & host verification
   # insecure_skip_verify = false
 
+  ## Publish all metrics of a write in a single message per topic, instead of
+  ## a message per metric. Combine with data formats supporting batches, such
+  ## as json with json_array = true.
+  # batch = false
+
   ## Data format to output.
   ## Each data format has it's own unique set of configuration options, read
   ## more about them here:
@@ -47,6 +52,8 @@ type MQTT struct {
 	Timeout     internal.Duration
 	TopicPrefix string
 	QoS         int `toml:"qos"`
+	// Batch publishes a single message per topic for all metrics of a write
+	Batch bool
 
 	// Path to CA file
 	SSLCA string `toml:"ssl_ca"`
@@ -116,6 +123,8 @@ func (m *MQTT) Write(metrics []telegraf.Metric) error {
 		hostname = ""
 	}
 
+	var topics []string
+	batches := make(map[string][]telegraf.Metric)
 	for _, metric := range metrics {
 		var t []string
 		if m.TopicPrefix != "" {
@@ -128,6 +137,14 @@ func (m *MQTT) Write(metrics []telegraf.Metric) error {
 		t = append(t, metric.Name())
 		topic := strings.Join(t, "/")
 
+		if m.Batch {
+			if _, ok := batches[topic]; !ok {
+				topics = append(topics, topic)
+			}
+			batches[topic] = append(batches[topic], metric)
+			continue
+		}
+
 		values, err := m.serializer.Serialize(metric)
 		if err != nil {
 			return fmt.Errorf("MQTT Could not serialize metric: %s",
@@ -142,6 +159,19 @@ func (m *MQTT) Write(metrics []telegraf.Metric) error {
 		}
 	}
 
+	serializer := serializers.NewBatchSerializer(m.serializer)
+	for _, topic := range topics {
+		body, err := serializer.SerializeBatch(batches[topic])
+		if err != nil {
+			return fmt.Errorf("MQTT Could not serialize metrics: %s", err)
+		}
+
+		err = m.publish(topic, string(body))
+		if err != nil {
+			return fmt.Errorf("Could not write to MQTT server, %s", err)
+		}
+	}
+
 	return nil
 }
 
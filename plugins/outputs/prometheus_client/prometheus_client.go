@@ -1,24 +1,63 @@
 package prometheus_client
 
 import (
+	"crypto/subtle"
+	"crypto/tls"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
-	"regexp"
+	"sort"
+	"strings"
 	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/plugins/outputs"
-	"github.com/prometheus/client_golang/prometheus"
+	serializer "github.com/influxdata/telegraf/plugins/serializers/prometheus"
+)
+
+const (
+	defaultListen             = "localhost:9126"
+	defaultPath               = "/metrics"
+	defaultExpirationInterval = 60 * time.Second
 )
 
-var invalidNameCharRE = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+// Sample is the last value of a series.
+type Sample struct {
+	Labels map[string]string
+	Value  float64
+	// Time after which the sample is no longer exposed.
+	Expiration time.Time
+}
+
+// MetricFamily holds the series of a metric name, by their label signature.
+type MetricFamily struct {
+	Samples   map[string]*Sample
+	ValueType dto.MetricType
+}
 
 type PrometheusClient struct {
-	Listen string
+	Listen             string
+	Path               string
+	ExpirationInterval internal.Duration `toml:"expiration_interval"`
+	StringAsLabel      bool              `toml:"string_as_label"`
+
+	BasicUsername string `toml:"basic_username"`
+	BasicPassword string `toml:"basic_password"`
+
+	// Path to the certificate and key of the listener, enabling https
+	TLSCert string `toml:"tls_cert"`
+	TLSKey  string `toml:"tls_key"`
 
-	metrics     map[string]prometheus.Metric
-	lastMetrics map[string]prometheus.Metric
+	listener net.Listener
+	fam      map[string]*MetricFamily
+	now      func() time.Time
 
 	sync.Mutex
 }
@@ -26,36 +65,99 @@ type PrometheusClient struct {
 var sampleConfig = `
   ## Address to listen on
   # listen = ":9126"
+
+  ## Path to publish the metrics on
+  # path = "/metrics"
+
+  ## Expiration interval for each series. Series which are not updated
+  ## within this interval are no longer exposed; 0 disables expiration.
+  # expiration_interval = "60s"
+
+  ## Send string fields as labels of the other fields of the metric.
+  ## String fields are dropped otherwise.
+  # string_as_label = false
+
+  ## Require HTTP basic authentication
+  # basic_username = "Foo"
+  # basic_password = "Bar"
+
+  ## Serve over https with the given certificate and key
+  # tls_cert = "/etc/telegraf/cert.pem"
+  # tls_key = "/etc/telegraf/key.pem"
 `
 
 func (p *PrometheusClient) Start() error {
-	p.metrics = make(map[string]prometheus.Metric)
-	p.lastMetrics = make(map[string]prometheus.Metric)
-	prometheus.Register(p)
-	defer func() {
-		if r := recover(); r != nil {
-			// recovering from panic here because there is no way to stop a
-			// running http go server except by a kill signal. Since the server
-			// does not stop on SIGHUP, Start() will panic when the process
-			// is reloaded.
-		}
-	}()
 	if p.Listen == "" {
-		p.Listen = "localhost:9126"
+		p.Listen = defaultListen
+	}
+	if p.Path == "" {
+		p.Path = defaultPath
+	}
+	if (p.TLSCert == "") != (p.TLSKey == "") {
+		return fmt.Errorf("prometheus_client: tls_cert and tls_key must be set together")
 	}
 
-	http.Handle("/metrics", prometheus.Handler())
-	server := &http.Server{
-		Addr: p.Listen,
+	p.Lock()
+	p.fam = make(map[string]*MetricFamily)
+	p.Unlock()
+
+	listener, err := p.listen()
+	if err != nil {
+		return err
 	}
+	p.listener = listener
+
+	// Every instance serves its own metrics, rather than registering in the
+	// global prometheus registry, so that several instances can run.
+	mux := http.NewServeMux()
+	mux.Handle(p.Path, p.auth(p))
+	server := &http.Server{Handler: mux}
 
-	go server.ListenAndServe()
+	go func() {
+		err := server.Serve(listener)
+		if err != nil && !strings.Contains(err.Error(), "use of closed network connection") {
+			log.Printf("E! prometheus_client: error serving %s: %s\n", p.Listen, err)
+		}
+	}()
 	return nil
 }
 
+func (p *PrometheusClient) listen() (net.Listener, error) {
+	if p.TLSCert == "" {
+		return net.Listen("tcp", p.Listen)
+	}
+
+	cert, err := tls.LoadX509KeyPair(p.TLSCert, p.TLSKey)
+	if err != nil {
+		return nil, fmt.Errorf("prometheus_client: could not load TLS key pair: %s", err)
+	}
+	return tls.Listen("tcp", p.Listen, &tls.Config{
+		Certificates: []tls.Certificate{cert},
+	})
+}
+
+// auth requires the basic authentication credentials, if configured.
+func (p *PrometheusClient) auth(h http.Handler) http.Handler {
+	if p.BasicUsername == "" && p.BasicPassword == "" {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok ||
+			subtle.ConstantTimeCompare([]byte(user), []byte(p.BasicUsername)) != 1 ||
+			subtle.ConstantTimeCompare([]byte(pass), []byte(p.BasicPassword)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Basic realm="telegraf"`)
+			http.Error(w, "Unauthorized.", http.StatusUnauthorized)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
 func (p *PrometheusClient) Stop() {
-	// TODO: Use a listener for http.Server that counts active connections
-	//       that can be stopped and closed gracefully
+	if p.listener != nil {
+		p.listener.Close()
+	}
 }
 
 func (p *PrometheusClient) Connect() error {
@@ -76,109 +178,207 @@ func (p *PrometheusClient) Description() string {
 	return "Configuration for the Prometheus client to spawn"
 }
 
-// Implements prometheus.Collector
-func (p *PrometheusClient) Describe(ch chan<- *prometheus.Desc) {
-	prometheus.NewGauge(prometheus.GaugeOpts{Name: "Dummy", Help: "Dummy"}).Describe(ch)
+// ServeHTTP exposes the metrics in the format negotiated with the scraper.
+// Every scrape sees the last value of each series until the series expires.
+func (p *PrometheusClient) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	format := expfmt.Negotiate(r.Header)
+	w.Header().Set("Content-Type", string(format))
+
+	enc := expfmt.NewEncoder(w, format)
+	for _, family := range p.families() {
+		if err := enc.Encode(family); err != nil {
+			log.Printf("E! prometheus_client: error encoding %s: %s\n",
+				family.GetName(), err)
+			return
+		}
+	}
 }
 
-// Implements prometheus.Collector
-func (p *PrometheusClient) Collect(ch chan<- prometheus.Metric) {
+// families returns the unexpired series, sorted by name and labels.
+func (p *PrometheusClient) families() []*dto.MetricFamily {
 	p.Lock()
 	defer p.Unlock()
 
-	if len(p.metrics) > 0 {
-		p.lastMetrics = make(map[string]prometheus.Metric)
-		for k, m := range p.metrics {
-			ch <- m
-			p.lastMetrics[k] = m
+	p.expire()
+
+	names := make([]string, 0, len(p.fam))
+	for name := range p.fam {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	families := make([]*dto.MetricFamily, 0, len(names))
+	for _, name := range names {
+		family := p.fam[name]
+		keys := make([]string, 0, len(family.Samples))
+		for key := range family.Samples {
+			keys = append(keys, key)
 		}
-		p.metrics = make(map[string]prometheus.Metric)
-	} else {
-		for _, m := range p.lastMetrics {
-			ch <- m
+		sort.Strings(keys)
+
+		mf := &dto.MetricFamily{
+			Name: proto.String(name),
+			Help: proto.String("Telegraf collected metric"),
+			Type: family.ValueType.Enum(),
+		}
+		for _, key := range keys {
+			mf.Metric = append(mf.Metric, newMetric(family.ValueType, family.Samples[key]))
+		}
+		families = append(families, mf)
+	}
+	return families
+}
+
+func newMetric(mType dto.MetricType, sample *Sample) *dto.Metric {
+	m := &dto.Metric{}
+	for _, k := range sortedKeys(sample.Labels) {
+		m.Label = append(m.Label, &dto.LabelPair{
+			Name:  proto.String(k),
+			Value: proto.String(sample.Labels[k]),
+		})
+	}
+	switch mType {
+	case dto.MetricType_COUNTER:
+		m.Counter = &dto.Counter{Value: proto.Float64(sample.Value)}
+	case dto.MetricType_GAUGE:
+		m.Gauge = &dto.Gauge{Value: proto.Float64(sample.Value)}
+	default:
+		m.Untyped = &dto.Untyped{Value: proto.Float64(sample.Value)}
+	}
+	return m
+}
+
+// expire removes the series which have not been updated within the
+// expiration interval.
+func (p *PrometheusClient) expire() {
+	if p.ExpirationInterval.Duration == 0 {
+		return
+	}
+	now := p.timeNow()
+	for name, family := range p.fam {
+		for key, sample := range family.Samples {
+			if now.After(sample.Expiration) {
+				delete(family.Samples, key)
+			}
+		}
+		if len(family.Samples) == 0 {
+			delete(p.fam, name)
 		}
 	}
 }
 
+func (p *PrometheusClient) timeNow() time.Time {
+	if p.now != nil {
+		return p.now()
+	}
+	return time.Now()
+}
+
 func (p *PrometheusClient) Write(metrics []telegraf.Metric) error {
 	p.Lock()
 	defer p.Unlock()
 
-	if len(metrics) == 0 {
-		return nil
+	if p.fam == nil {
+		p.fam = make(map[string]*MetricFamily)
 	}
+	expiration := p.timeNow().Add(p.ExpirationInterval.Duration)
 
 	for _, point := range metrics {
-		key := point.Name()
-		key = invalidNameCharRE.ReplaceAllString(key, "_")
+		fields := point.Fields()
 
-		// convert tags into prometheus labels
-		var labels []string
-		l := prometheus.Labels{}
+		// convert tags, and string fields if requested, into labels
+		labels := make(map[string]string)
 		for k, v := range point.Tags() {
-			k = invalidNameCharRE.ReplaceAllString(k, "_")
-			if len(k) == 0 {
-				continue
+			addLabel(labels, k, v)
+		}
+		if p.StringAsLabel {
+			for k, v := range fields {
+				if s, ok := v.(string); ok {
+					addLabel(labels, k, s)
+				}
 			}
-			labels = append(labels, k)
-			l[k] = v
 		}
+		key := signature(labels)
 
 		// Get a type if it's available, defaulting to Untyped
-		var mType prometheus.ValueType
+		var mType dto.MetricType
 		switch point.Type() {
 		case telegraf.Counter:
-			mType = prometheus.CounterValue
+			mType = dto.MetricType_COUNTER
 		case telegraf.Gauge:
-			mType = prometheus.GaugeValue
+			mType = dto.MetricType_GAUGE
 		default:
-			mType = prometheus.UntypedValue
+			mType = dto.MetricType_UNTYPED
 		}
 
-		for n, val := range point.Fields() {
-			// Ignore string and bool fields.
-			switch val.(type) {
-			case string:
-				continue
-			case bool:
-				continue
-			}
-
-			// sanitize the measurement name
-			n = invalidNameCharRE.ReplaceAllString(n, "_")
-			var mname string
-			if n == "value" {
-				mname = key
-			} else {
-				mname = fmt.Sprintf("%s_%s", key, n)
-			}
-
-			desc := prometheus.NewDesc(mname, "Telegraf collected metric", nil, l)
-			var metric prometheus.Metric
-			var err error
-
-			// switch for field type
+		for n, val := range fields {
+			var value float64
 			switch val := val.(type) {
 			case int64:
-				metric, err = prometheus.NewConstMetric(desc, mType, float64(val))
+				value = float64(val)
 			case float64:
-				metric, err = prometheus.NewConstMetric(desc, mType, val)
+				value = val
 			default:
+				// Ignore string and bool fields.
 				continue
 			}
-			if err != nil {
-				log.Printf("E! Error creating prometheus metric, "+
-					"key: %s, labels: %v,\nerr: %s\n",
-					mname, l, err.Error())
+
+			mname := serializer.MetricName(point.Name(), n)
+			family, ok := p.fam[mname]
+			if !ok {
+				family = &MetricFamily{
+					Samples:   make(map[string]*Sample),
+					ValueType: mType,
+				}
+				p.fam[mname] = family
+			} else if family.ValueType != mType {
+				log.Printf("E! prometheus_client: mismatched type for %s, skipping\n", mname)
+				continue
+			}
+
+			family.Samples[key] = &Sample{
+				Labels:     labels,
+				Value:      value,
+				Expiration: expiration,
 			}
-			p.metrics[desc.String()] = metric
 		}
 	}
 	return nil
 }
 
+func addLabel(labels map[string]string, k, v string) {
+	k = serializer.LabelName(k)
+	if len(k) == 0 {
+		return
+	}
+	labels[k] = v
+}
+
+// signature identifies a series of a family by its labels.
+func signature(labels map[string]string) string {
+	var buf []byte
+	for _, k := range sortedKeys(labels) {
+		buf = append(buf, k...)
+		buf = append(buf, 0xff)
+		buf = append(buf, labels[k]...)
+		buf = append(buf, 0xff)
+	}
+	return string(buf)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 func init() {
 	outputs.Add("prometheus_client", func() telegraf.Output {
-		return &PrometheusClient{}
+		return &PrometheusClient{
+			ExpirationInterval: internal.Duration{Duration: defaultExpirationInterval},
+		}
 	})
 }
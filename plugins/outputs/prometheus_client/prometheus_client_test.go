@@ -1,12 +1,18 @@
 package prometheus_client
 
 import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
 	"testing"
 	"time"
 
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/require"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/plugins/inputs/prometheus"
 	"github.com/influxdata/telegraf/testutil"
 )
@@ -93,3 +99,155 @@ func TestPrometheusWritePointEmptyTag(t *testing.T) {
 			map[string]interface{}{"value": e.value})
 	}
 }
+
+func collect(p *PrometheusClient) []*dto.Metric {
+	var metrics []*dto.Metric
+	for _, family := range p.families() {
+		metrics = append(metrics, family.Metric...)
+	}
+	return metrics
+}
+
+func TestPrometheusCollectIsStable(t *testing.T) {
+	p := &PrometheusClient{}
+	m := testutil.TestMetric(1.0, "test")
+	require.NoError(t, p.Write([]telegraf.Metric{m}))
+
+	// Scrapes see the same series until they are updated.
+	require.Len(t, collect(p), 1)
+	require.Len(t, collect(p), 1)
+
+	m = testutil.TestMetric(2.0, "test")
+	require.NoError(t, p.Write([]telegraf.Metric{m}))
+
+	metrics := collect(p)
+	require.Len(t, metrics, 1)
+	out := metrics[0]
+	require.Equal(t, 2.0, out.GetUntyped().GetValue())
+}
+
+func TestPrometheusExpiration(t *testing.T) {
+	now := time.Now()
+	p := &PrometheusClient{
+		ExpirationInterval: internal.Duration{Duration: 10 * time.Second},
+		now:                func() time.Time { return now },
+	}
+	m1, _ := telegraf.NewMetric("foo", map[string]string{"host": "a"},
+		map[string]interface{}{"value": 1.0}, now)
+	require.NoError(t, p.Write([]telegraf.Metric{m1}))
+
+	now = now.Add(5 * time.Second)
+	m2, _ := telegraf.NewMetric("foo", map[string]string{"host": "b"},
+		map[string]interface{}{"value": 2.0}, now)
+	require.NoError(t, p.Write([]telegraf.Metric{m2}))
+	require.Len(t, collect(p), 2)
+
+	// Only the series of host a expired.
+	now = now.Add(6 * time.Second)
+	metrics := collect(p)
+	require.Len(t, metrics, 1)
+	out := metrics[0]
+	require.Equal(t, 2.0, out.GetUntyped().GetValue())
+
+	now = now.Add(5 * time.Second)
+	require.Len(t, collect(p), 0)
+	require.Len(t, p.fam, 0)
+}
+
+func TestPrometheusStringAsLabel(t *testing.T) {
+	p := &PrometheusClient{StringAsLabel: true}
+	m, _ := telegraf.NewMetric("foo", map[string]string{"host": "a"},
+		map[string]interface{}{"value": 1.0, "status": "ok"}, time.Now())
+	require.NoError(t, p.Write([]telegraf.Metric{m}))
+
+	metrics := collect(p)
+	require.Len(t, metrics, 1)
+	out := metrics[0]
+	labels := make(map[string]string)
+	for _, l := range out.GetLabel() {
+		labels[l.GetName()] = l.GetValue()
+	}
+	require.Equal(t, map[string]string{"host": "a", "status": "ok"}, labels)
+}
+
+func TestPrometheusMismatchedType(t *testing.T) {
+	p := &PrometheusClient{}
+	m1, _ := telegraf.NewCounterMetric("foo", nil,
+		map[string]interface{}{"value": 1.0}, time.Now())
+	m2, _ := telegraf.NewGaugeMetric("foo", map[string]string{"host": "a"},
+		map[string]interface{}{"value": 2.0}, time.Now())
+	require.NoError(t, p.Write([]telegraf.Metric{m1, m2}))
+
+	metrics := collect(p)
+	require.Len(t, metrics, 1)
+	out := metrics[0]
+	require.Equal(t, 1.0, out.GetCounter().GetValue())
+}
+
+func TestPrometheusBasicAuth(t *testing.T) {
+	p := &PrometheusClient{BasicUsername: "foo", BasicPassword: "bar"}
+	h := p.auth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+
+	req.SetBasicAuth("foo", "baz")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+
+	req.SetBasicAuth("foo", "bar")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestPrometheusServeHTTP(t *testing.T) {
+	p := &PrometheusClient{}
+	m1, _ := telegraf.NewGaugeMetric("foo", map[string]string{"host": "a"},
+		map[string]interface{}{"value": 1.0}, time.Now())
+	m2, _ := telegraf.NewCounterMetric("bar", nil,
+		map[string]interface{}{"count": int64(2)}, time.Now())
+	require.NoError(t, p.Write([]telegraf.Metric{m1, m2}))
+
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, `# HELP bar_count Telegraf collected metric
+# TYPE bar_count counter
+bar_count 2
+# HELP foo Telegraf collected metric
+# TYPE foo gauge
+foo{host="a"} 1
+`, w.Body.String())
+}
+
+func TestPrometheusMultipleInstances(t *testing.T) {
+	var clients []*PrometheusClient
+	for i := 0; i < 2; i++ {
+		p := &PrometheusClient{Listen: "127.0.0.1:0"}
+		require.NoError(t, p.Start())
+		defer p.Stop()
+		clients = append(clients, p)
+	}
+
+	for i, p := range clients {
+		m, _ := telegraf.NewMetric("foo", nil,
+			map[string]interface{}{"value": float64(i)}, time.Now())
+		require.NoError(t, p.Write([]telegraf.Metric{m}))
+	}
+
+	for i, p := range clients {
+		resp, err := http.Get("http://" + p.listener.Addr().String() + "/metrics")
+		require.NoError(t, err)
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.NoError(t, err)
+		require.Contains(t, string(body), "\nfoo "+strconv.Itoa(i)+"\n")
+	}
+}
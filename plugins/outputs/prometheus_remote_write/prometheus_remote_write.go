@@ -0,0 +1,240 @@
+package prometheus_remote_write
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/snappy"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/outputs"
+	serializer "github.com/influxdata/telegraf/plugins/serializers/prometheus"
+)
+
+var sampleConfig = `
+  ## URL of the remote write endpoint
+  url = "http://127.0.0.1:9090/api/v1/write"
+
+  ## Timeout of the HTTP requests
+  # timeout = "5s"
+
+  ## HTTP basic authentication
+  # username = "telegraf"
+  # password = "metricsmetricsmetricsmetrics"
+
+  ## Bearer token sent in the Authorization header
+  # bearer_token = ""
+
+  ## Optional SSL Config
+  # ssl_ca = "/etc/telegraf/ca.pem"
+  # ssl_cert = "/etc/telegraf/cert.pem"
+  # ssl_key = "/etc/telegraf/key.pem"
+  ## Use SSL but skip chain & host verification
+  # insecure_skip_verify = false
+`
+
+type PrometheusRemoteWrite struct {
+	URL         string `toml:"url"`
+	Timeout     internal.Duration
+	Username    string
+	Password    string
+	BearerToken string
+
+	// Path to CA file
+	SSLCA string `toml:"ssl_ca"`
+	// Path to host cert file
+	SSLCert string `toml:"ssl_cert"`
+	// Path to cert key file
+	SSLKey string `toml:"ssl_key"`
+	// Use SSL but skip chain & host verification
+	InsecureSkipVerify bool
+
+	client *http.Client
+}
+
+func (p *PrometheusRemoteWrite) Connect() error {
+	if p.URL == "" {
+		return fmt.Errorf("prometheus_remote_write: url is required")
+	}
+	if _, err := url.Parse(p.URL); err != nil {
+		return fmt.Errorf("prometheus_remote_write: invalid url %s: %s", p.URL, err)
+	}
+
+	if p.Username != "" && p.BearerToken != "" {
+		return fmt.Errorf("prometheus_remote_write: basic authentication and bearer token are exclusive")
+	}
+
+	tlsCfg, err := internal.GetTLSConfig(
+		p.SSLCert, p.SSLKey, p.SSLCA, p.InsecureSkipVerify)
+	if err != nil {
+		return err
+	}
+
+	p.client = internal.NewHTTPClient(p.Timeout.Duration, tlsCfg)
+	return nil
+}
+
+func (p *PrometheusRemoteWrite) Close() error {
+	return nil
+}
+
+func (p *PrometheusRemoteWrite) Description() string {
+	return "Configuration for the Prometheus remote write client"
+}
+
+func (p *PrometheusRemoteWrite) SampleConfig() string {
+	return sampleConfig
+}
+
+// Write sends the metrics in a single remote write request. Failed requests
+// are retried if internal.RetriableStatus holds for the response, and
+// dropped otherwise.
+func (p *PrometheusRemoteWrite) Write(metrics []telegraf.Metric) error {
+	series := NewTimeSeries(metrics)
+	if len(series) == 0 {
+		return nil
+	}
+
+	data, err := proto.Marshal(&WriteRequest{Timeseries: series})
+	if err != nil {
+		return fmt.Errorf("prometheus_remote_write: unable to marshal request: %s", err)
+	}
+	body := snappy.Encode(nil, data)
+
+	req, err := http.NewRequest("POST", p.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("prometheus_remote_write: unable to create request: %s", err)
+	}
+	req.Header.Set("User-Agent", "Telegraf")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	if p.Username != "" || p.Password != "" {
+		req.SetBasicAuth(p.Username, p.Password)
+	}
+	if p.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+p.BearerToken)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("prometheus_remote_write: error sending metrics to %s: %s", p.URL, err)
+	}
+	defer resp.Body.Close()
+	msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	if internal.RetriableStatus(resp.StatusCode) {
+		return fmt.Errorf("prometheus_remote_write: %s responded %s: %s",
+			p.URL, resp.Status, bytes.TrimSpace(msg))
+	}
+	log.Printf("E! prometheus_remote_write: %s responded %s, dropping %d metrics: %s\n",
+		p.URL, resp.Status, len(metrics), bytes.TrimSpace(msg))
+	return nil
+}
+
+// NewTimeSeries converts the numeric fields of the metrics into time series
+// named <measurement>_<field>, or <measurement> for the fields named value,
+// labelled by the tags of the metrics. The samples of a series are sorted by
+// time; string and boolean fields are skipped.
+func NewTimeSeries(metrics []telegraf.Metric) []*TimeSeries {
+	var series []*TimeSeries
+	index := make(map[string]*TimeSeries)
+
+	for _, metric := range metrics {
+		tags := metric.Tags()
+		ts := metric.UnixNano() / int64(time.Millisecond)
+
+		for field, v := range metric.Fields() {
+			var value float64
+			switch v := v.(type) {
+			case int64:
+				value = float64(v)
+			case float64:
+				value = v
+			default:
+				continue
+			}
+
+			labels := newLabels(serializer.MetricName(metric.Name(), field), tags)
+			key := signature(labels)
+			s, ok := index[key]
+			if !ok {
+				s = &TimeSeries{Labels: labels}
+				index[key] = s
+				series = append(series, s)
+			}
+			s.Samples = append(s.Samples, &Sample{Value: value, Timestamp: ts})
+		}
+	}
+
+	for _, s := range series {
+		sort.Stable(byTimestamp(s.Samples))
+	}
+	return series
+}
+
+// newLabels returns the labels of a series sorted by name, as required by
+// the protocol.
+func newLabels(name string, tags map[string]string) []*Label {
+	labels := []*Label{{Name: "__name__", Value: name}}
+	seen := map[string]bool{"__name__": true}
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		name := serializer.LabelName(k)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		labels = append(labels, &Label{Name: name, Value: tags[k]})
+	}
+	sort.Sort(byName(labels))
+	return labels
+}
+
+func signature(labels []*Label) string {
+	var buf bytes.Buffer
+	for _, l := range labels {
+		buf.WriteString(l.Name)
+		buf.WriteByte(0xff)
+		buf.WriteString(l.Value)
+		buf.WriteByte(0xff)
+	}
+	return buf.String()
+}
+
+type byName []*Label
+
+func (l byName) Len() int           { return len(l) }
+func (l byName) Swap(i, j int)      { l[i], l[j] = l[j], l[i] }
+func (l byName) Less(i, j int) bool { return l[i].Name < l[j].Name }
+
+type byTimestamp []*Sample
+
+func (s byTimestamp) Len() int           { return len(s) }
+func (s byTimestamp) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s byTimestamp) Less(i, j int) bool { return s[i].Timestamp < s[j].Timestamp }
+
+func init() {
+	outputs.Add("prometheus_remote_write", func() telegraf.Output {
+		return &PrometheusRemoteWrite{
+			Timeout: internal.Duration{Duration: 5 * time.Second},
+		}
+	})
+}
@@ -0,0 +1,113 @@
+package prometheus_remote_write
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/snappy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestNewTimeSeries(t *testing.T) {
+	now := time.Unix(1257894000, 0)
+	m1, _ := telegraf.NewMetric("cpu", map[string]string{"host": "a", "cpu-id": "0"},
+		map[string]interface{}{"usage_idle": 91.5, "value": int64(3), "state": "ok"}, now)
+	m2, _ := telegraf.NewMetric("cpu", map[string]string{"host": "a", "cpu-id": "0"},
+		map[string]interface{}{"usage_idle": 90.0}, now.Add(-time.Second))
+
+	series := NewTimeSeries([]telegraf.Metric{m1, m2})
+	require.Len(t, series, 2)
+
+	byName := make(map[string]*TimeSeries)
+	for _, s := range series {
+		byName[s.Labels[0].Value] = s
+	}
+
+	s := byName["cpu_usage_idle"]
+	require.NotNil(t, s)
+	assert.Equal(t, []*Label{
+		{Name: "__name__", Value: "cpu_usage_idle"},
+		{Name: "cpu_id", Value: "0"},
+		{Name: "host", Value: "a"},
+	}, s.Labels)
+	assert.Equal(t, []*Sample{
+		{Value: 90.0, Timestamp: 1257893999000},
+		{Value: 91.5, Timestamp: 1257894000000},
+	}, s.Samples)
+
+	s = byName["cpu"]
+	require.NotNil(t, s)
+	assert.Equal(t, []*Sample{{Value: 3, Timestamp: 1257894000000}}, s.Samples)
+}
+
+func TestWrite(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "snappy", r.Header.Get("Content-Encoding"))
+		assert.Equal(t, "application/x-protobuf", r.Header.Get("Content-Type"))
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		data, err := snappy.Decode(nil, body)
+		require.NoError(t, err)
+		var req WriteRequest
+		require.NoError(t, proto.Unmarshal(data, &req))
+		require.Len(t, req.Timeseries, 2)
+		assert.Equal(t, "test1", req.Timeseries[0].Labels[0].Value)
+		assert.Equal(t, "test2", req.Timeseries[1].Labels[0].Value)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	p := &PrometheusRemoteWrite{URL: ts.URL, BearerToken: "token"}
+	require.NoError(t, p.Connect())
+	require.NoError(t, p.Write([]telegraf.Metric{
+		testutil.TestMetric(1.0),
+		testutil.TestMetric(2.0, "test2"),
+	}))
+}
+
+func TestWriteStatus(t *testing.T) {
+	tests := []struct {
+		status int
+		err    bool
+	}{
+		{http.StatusOK, false},
+		{http.StatusBadRequest, false},
+		{http.StatusTooManyRequests, true},
+		{http.StatusServiceUnavailable, true},
+	}
+
+	for _, tt := range tests {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+		}))
+
+		p := &PrometheusRemoteWrite{URL: ts.URL}
+		require.NoError(t, p.Connect())
+		err := p.Write([]telegraf.Metric{testutil.TestMetric(1.0)})
+		if tt.err {
+			assert.Error(t, err, "status %d", tt.status)
+		} else {
+			assert.NoError(t, err, "status %d", tt.status)
+		}
+		ts.Close()
+	}
+}
+
+func TestConnectExclusiveAuth(t *testing.T) {
+	p := &PrometheusRemoteWrite{
+		URL:         "http://localhost:9090/api/v1/write",
+		Username:    "telegraf",
+		BearerToken: "token",
+	}
+	require.Error(t, p.Connect())
+}
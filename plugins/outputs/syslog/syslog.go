@@ -0,0 +1,418 @@
+package syslog
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/outputs"
+)
+
+const (
+	nilValue        = "-"
+	timestampFormat = "2006-01-02T15:04:05.000000Z07:00"
+
+	defaultSDID     = "telegraf@32473"
+	defaultSeverity = "notice"
+	defaultFacility = "user"
+
+	maxHostnameLength = 255
+	maxAppNameLength  = 48
+	maxSDNameLength   = 32
+)
+
+var severities = map[string]int{
+	"emerg":         0,
+	"emergency":     0,
+	"alert":         1,
+	"crit":          2,
+	"critical":      2,
+	"err":           3,
+	"error":         3,
+	"warn":          4,
+	"warning":       4,
+	"notice":        5,
+	"info":          6,
+	"informational": 6,
+	"debug":         7,
+}
+
+var facilities = map[string]int{
+	"kern":         0,
+	"user":         1,
+	"mail":         2,
+	"daemon":       3,
+	"auth":         4,
+	"syslog":       5,
+	"lpr":          6,
+	"news":         7,
+	"uucp":         8,
+	"cron":         9,
+	"authpriv":     10,
+	"ftp":          11,
+	"ntp":          12,
+	"security":     13,
+	"console":      14,
+	"solaris-cron": 15,
+	"local0":       16,
+	"local1":       17,
+	"local2":       18,
+	"local3":       19,
+	"local4":       20,
+	"local5":       21,
+	"local6":       22,
+	"local7":       23,
+}
+
+var sampleConfig = `
+  ## URL of the syslog server, the protocol being one of "udp", "tcp" or
+  ## "tls". Messages sent over tcp and tls are framed by octet counting.
+  address = "tcp://127.0.0.1:6514"
+
+  ## Timeout of the connection and of the writes
+  # timeout = "5s"
+
+  ## SD-ID of the structured data element holding the tags and fields of
+  ## the metrics, in the name@<private enterprise number> format.
+  # sdid = "telegraf@32473"
+
+  ## Field holding the text of the messages, which is not added to the
+  ## structured data.
+  # message_field = "message"
+
+  ## Severity and facility of the messages, by name or number, unless set by
+  ## the tag or field named by severity_key and facility_key.
+  # default_severity = "notice"
+  # default_facility = "user"
+  # severity_key = ""
+  # facility_key = ""
+
+  ## Optional SSL Config
+  # ssl_ca = "/etc/telegraf/ca.pem"
+  # ssl_cert = "/etc/telegraf/cert.pem"
+  # ssl_key = "/etc/telegraf/key.pem"
+  ## Use SSL but skip chain & host verification
+  # insecure_skip_verify = false
+`
+
+type Syslog struct {
+	Address         string
+	Timeout         internal.Duration
+	SDID            string `toml:"sdid"`
+	MessageField    string
+	DefaultSeverity string
+	DefaultFacility string
+	SeverityKey     string
+	FacilityKey     string
+
+	// Path to CA file
+	SSLCA string `toml:"ssl_ca"`
+	// Path to host cert file
+	SSLCert string `toml:"ssl_cert"`
+	// Path to cert key file
+	SSLKey string `toml:"ssl_key"`
+	// Use SSL but skip chain & host verification
+	InsecureSkipVerify bool
+
+	network  string
+	host     string
+	severity int
+	facility int
+	hostname string
+	conn     net.Conn
+}
+
+func (s *Syslog) Connect() error {
+	u, err := url.Parse(s.Address)
+	if err != nil {
+		return fmt.Errorf("syslog: invalid address %s: %s", s.Address, err)
+	}
+	switch u.Scheme {
+	case "udp", "tcp", "tls":
+	default:
+		return fmt.Errorf("syslog: unsupported protocol %q in %s", u.Scheme, s.Address)
+	}
+	s.network, s.host = u.Scheme, u.Host
+
+	if s.SDID == "" {
+		s.SDID = defaultSDID
+	}
+	if s.DefaultSeverity == "" {
+		s.DefaultSeverity = defaultSeverity
+	}
+	if s.DefaultFacility == "" {
+		s.DefaultFacility = defaultFacility
+	}
+	if err := s.validateSDID(); err != nil {
+		return err
+	}
+	if s.severity, err = lookup(severities, s.DefaultSeverity, 7); err != nil {
+		return fmt.Errorf("syslog: invalid default_severity: %s", err)
+	}
+	if s.facility, err = lookup(facilities, s.DefaultFacility, 23); err != nil {
+		return fmt.Errorf("syslog: invalid default_facility: %s", err)
+	}
+
+	s.hostname, _ = os.Hostname()
+
+	return s.dial()
+}
+
+func (s *Syslog) validateSDID() error {
+	if len(s.SDID) > maxSDNameLength || sdName(s.SDID) != s.SDID {
+		return fmt.Errorf("syslog: invalid sdid %q", s.SDID)
+	}
+	return nil
+}
+
+func (s *Syslog) dial() error {
+	var conn net.Conn
+	var err error
+	switch s.network {
+	case "tls":
+		var tlsCfg *tls.Config
+		tlsCfg, err = internal.GetTLSConfig(
+			s.SSLCert, s.SSLKey, s.SSLCA, s.InsecureSkipVerify)
+		if err != nil {
+			return err
+		}
+		if tlsCfg == nil {
+			tlsCfg = &tls.Config{}
+		}
+		dialer := &net.Dialer{Timeout: s.Timeout.Duration}
+		conn, err = tls.DialWithDialer(dialer, "tcp", s.host, tlsCfg)
+	default:
+		conn, err = net.DialTimeout(s.network, s.host, s.Timeout.Duration)
+	}
+	if err != nil {
+		return fmt.Errorf("syslog: unable to connect to %s: %s", s.Address, err)
+	}
+	s.conn = conn
+	return nil
+}
+
+func (s *Syslog) Close() error {
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+func (s *Syslog) SampleConfig() string {
+	return sampleConfig
+}
+
+func (s *Syslog) Description() string {
+	return "Configuration for Syslog server to send metrics to"
+}
+
+// Write sends a RFC5424 message per metric. The connection is closed on
+// errors and opened again on the next write.
+func (s *Syslog) Write(metrics []telegraf.Metric) error {
+	if s.conn == nil {
+		if err := s.dial(); err != nil {
+			return err
+		}
+	}
+
+	for _, metric := range metrics {
+		msg := s.Message(metric)
+		if s.network != "udp" {
+			msg = append([]byte(strconv.Itoa(len(msg))+" "), msg...)
+		}
+		if s.Timeout.Duration > 0 {
+			s.conn.SetWriteDeadline(time.Now().Add(s.Timeout.Duration))
+		}
+		if _, err := s.conn.Write(msg); err != nil {
+			s.Close()
+			return fmt.Errorf("syslog: error writing to %s: %s", s.Address, err)
+		}
+	}
+	return nil
+}
+
+// Message formats a metric into a RFC5424 message:
+//
+//	<PRI>1 TIMESTAMP HOSTNAME APP-NAME - - [SDID tag="v" field="v"] MSG
+//
+// The APP-NAME is the measurement name and the HOSTNAME the host tag. The
+// structured data element holds the tags and the fields, but the message
+// field which is the MSG.
+func (s *Syslog) Message(metric telegraf.Metric) []byte {
+	tags := metric.Tags()
+	fields := metric.Fields()
+
+	severity := s.keyValue(s.SeverityKey, severities, 7, s.severity, tags, fields)
+	facility := s.keyValue(s.FacilityKey, facilities, 23, s.facility, tags, fields)
+
+	hostname := tags["host"]
+	if hostname == "" {
+		hostname = s.hostname
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "<%d>1 %s %s %s %s %s ",
+		facility*8+severity,
+		metric.Time().Format(timestampFormat),
+		header(hostname, maxHostnameLength),
+		header(metric.Name(), maxAppNameLength),
+		nilValue,
+		nilValue,
+	)
+
+	buf.WriteByte('[')
+	buf.WriteString(s.SDID)
+	for _, k := range sortedKeys(tags) {
+		writeParam(&buf, k, tags[k])
+	}
+	fieldKeys := make([]string, 0, len(fields))
+	for k := range fields {
+		if k != s.MessageField {
+			fieldKeys = append(fieldKeys, k)
+		}
+	}
+	sort.Strings(fieldKeys)
+	for _, k := range fieldKeys {
+		writeParam(&buf, k, formatValue(fields[k]))
+	}
+	buf.WriteByte(']')
+
+	if v, ok := fields[s.MessageField]; ok {
+		buf.WriteByte(' ')
+		buf.WriteString(formatValue(v))
+	}
+	return buf.Bytes()
+}
+
+// keyValue returns the severity or facility set by the tag or field named
+// key, or def if it is missing or invalid.
+func (s *Syslog) keyValue(
+	key string,
+	names map[string]int,
+	max int,
+	def int,
+	tags map[string]string,
+	fields map[string]interface{},
+) int {
+	if key == "" {
+		return def
+	}
+	var value string
+	if v, ok := tags[key]; ok {
+		value = v
+	} else if v, ok := fields[key]; ok {
+		value = formatValue(v)
+	} else {
+		return def
+	}
+	n, err := lookup(names, value, max)
+	if err != nil {
+		return def
+	}
+	return n
+}
+
+// lookup returns the number of a severity or facility, given by name or
+// number.
+func lookup(names map[string]int, value string, max int) (int, error) {
+	if n, ok := names[strings.ToLower(value)]; ok {
+		return n, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 || n > max {
+		return 0, fmt.Errorf("unknown value %q", value)
+	}
+	return n, nil
+}
+
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// header returns a header field of printable US-ASCII characters, replacing
+// the others, truncated to max characters.
+func header(s string, max int) string {
+	b := []byte(s)
+	for i, c := range b {
+		if c < 33 || c > 126 {
+			b[i] = '_'
+		}
+	}
+	if len(b) > max {
+		b = b[:max]
+	}
+	if len(b) == 0 {
+		return nilValue
+	}
+	return string(b)
+}
+
+// sdName returns a SD-NAME, replacing the characters which are not allowed.
+func sdName(s string) string {
+	b := []byte(header(s, maxSDNameLength))
+	for i, c := range b {
+		if c == '=' || c == ']' || c == '"' {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
+
+func writeParam(buf *bytes.Buffer, name, value string) {
+	buf.WriteByte(' ')
+	buf.WriteString(sdName(name))
+	buf.WriteString(`="`)
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; c {
+		case '"', '\\', ']':
+			buf.WriteByte('\\')
+			buf.WriteByte(c)
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	buf.WriteByte('"')
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func init() {
+	outputs.Add("syslog", func() telegraf.Output {
+		return &Syslog{
+			Timeout:         internal.Duration{Duration: 5 * time.Second},
+			SDID:            defaultSDID,
+			MessageField:    "message",
+			DefaultSeverity: defaultSeverity,
+			DefaultFacility: defaultFacility,
+		}
+	})
+}
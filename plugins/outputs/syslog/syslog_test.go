@@ -0,0 +1,146 @@
+package syslog
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+)
+
+func testMetric() telegraf.Metric {
+	m, _ := telegraf.NewMetric("logparser_grok",
+		map[string]string{"host": "example.org", "path": "/var/log/nginx]"},
+		map[string]interface{}{
+			"message":  `GET "/index.html"`,
+			"status":   int64(404),
+			"duration": 1.5,
+			"level":    "err",
+		},
+		time.Date(2016, 11, 22, 13, 0, 0, 123000000, time.UTC))
+	return m
+}
+
+func TestMessage(t *testing.T) {
+	s := &Syslog{
+		SDID:         "telegraf@32473",
+		MessageField: "message",
+		severity:     5,
+		facility:     1,
+	}
+
+	assert.Equal(t, `<13>1 2016-11-22T13:00:00.123000Z example.org logparser_grok - - `+
+		`[telegraf@32473 host="example.org" path="/var/log/nginx\]" duration="1.5" level="err" status="404"] `+
+		`GET "/index.html"`,
+		string(s.Message(testMetric())))
+}
+
+func TestMessageSeverityFacility(t *testing.T) {
+	s := &Syslog{
+		SDID:        "telegraf@32473",
+		SeverityKey: "level",
+		FacilityKey: "path",
+		severity:    5,
+		facility:    16,
+	}
+
+	// The severity is set by the level field, the facility stays the
+	// default as the path tag is no facility.
+	msg := string(s.Message(testMetric()))
+	assert.True(t, strings.HasPrefix(msg, "<131>1 "), msg)
+	// Without message field, the message field is structured data.
+	assert.True(t, strings.HasSuffix(msg, `message="GET \"/index.html\"" status="404"]`), msg)
+}
+
+func TestConnectInvalid(t *testing.T) {
+	tests := []*Syslog{
+		{Address: "http://localhost:6514"},
+		{Address: "udp://localhost:6514", SDID: "tele graf"},
+		{Address: "udp://localhost:6514", DefaultSeverity: "loud"},
+		{Address: "udp://localhost:6514", DefaultFacility: "24"},
+	}
+	for _, s := range tests {
+		assert.Error(t, s.Connect(), "%+v", s)
+	}
+}
+
+func TestWriteUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	s := &Syslog{Address: "udp://" + conn.LocalAddr().String()}
+	require.NoError(t, s.Connect())
+	defer s.Close()
+	require.NoError(t, s.Write([]telegraf.Metric{testMetric()}))
+
+	buf := make([]byte, 1024)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+	assert.Equal(t, string(s.Message(testMetric())), string(buf[:n]))
+}
+
+func TestWriteTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	s := &Syslog{Address: "tcp://" + listener.Addr().String()}
+	require.NoError(t, s.Connect())
+	defer s.Close()
+
+	conn, err := listener.Accept()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	require.NoError(t, s.Write([]telegraf.Metric{testMetric(), testMetric()}))
+
+	expected := string(s.Message(testMetric()))
+	r := bufio.NewReader(conn)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	for i := 0; i < 2; i++ {
+		length, err := r.ReadString(' ')
+		require.NoError(t, err)
+		n, err := strconv.Atoi(strings.TrimSpace(length))
+		require.NoError(t, err)
+		msg := make([]byte, n)
+		_, err = io.ReadFull(r, msg)
+		require.NoError(t, err)
+		assert.Equal(t, expected, string(msg))
+	}
+}
+
+func TestWriteTimeout(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	s := &Syslog{
+		Address: "tcp://" + listener.Addr().String(),
+		Timeout: internal.Duration{Duration: 100 * time.Millisecond},
+	}
+	require.NoError(t, s.Connect())
+	defer s.Close()
+
+	// the server never reads
+	conn, err := listener.Accept()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	m, _ := telegraf.NewMetric("app", nil,
+		map[string]interface{}{"message": strings.Repeat("x", 1<<20)}, time.Now())
+	var metrics []telegraf.Metric
+	for i := 0; i < 64; i++ {
+		metrics = append(metrics, m)
+	}
+	assert.Error(t, s.Write(metrics))
+}
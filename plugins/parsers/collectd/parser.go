@@ -0,0 +1,236 @@
+package collectd
+
+import (
+	"encoding/binary"
+	"fmt"
+	"log"
+	"math"
+	"os"
+
+	"collectd.org/api"
+	"collectd.org/network"
+
+	"github.com/influxdata/telegraf"
+)
+
+const (
+	DefaultAuthFile = "/etc/collectd/auth_file"
+
+	// typeSignSHA256 is the type of the signature part of a packet.
+	typeSignSHA256 = 0x0200
+)
+
+type CollectdParser struct {
+	// DefaultTags will be added to every parsed metric
+	DefaultTags map[string]string
+
+	// ParseMultiValue is either "split" to emit one metric per value of a
+	// value list, or "join" to emit one metric holding all values as fields.
+	ParseMultiValue string
+
+	popts network.ParseOpts
+}
+
+// NewCollectdParser returns a parser of the collectd binary network
+// protocol. securityLevel is one of "none", "sign" or "encrypt"; signed and
+// encrypted packets are verified with the users and passwords of the auth
+// file. The data source names of the values are looked up in the types.db
+// files.
+func NewCollectdParser(
+	authFile string,
+	securityLevel string,
+	typesDBPaths []string,
+	parseMultiValue string,
+) (*CollectdParser, error) {
+	popts := network.ParseOpts{}
+
+	switch securityLevel {
+	case "", "none":
+		popts.SecurityLevel = network.None
+	case "sign":
+		popts.SecurityLevel = network.Sign
+	case "encrypt":
+		popts.SecurityLevel = network.Encrypt
+	default:
+		return nil, fmt.Errorf("invalid collectd security level %q", securityLevel)
+	}
+	if popts.SecurityLevel != network.None || authFile != "" {
+		if authFile == "" {
+			authFile = DefaultAuthFile
+		}
+		popts.PasswordLookup = network.NewAuthFile(authFile)
+	}
+
+	for _, path := range typesDBPaths {
+		db, err := loadTypesDB(path)
+		if err != nil {
+			return nil, err
+		}
+		if popts.TypesDB == nil {
+			popts.TypesDB = db
+		} else {
+			popts.TypesDB.Merge(db)
+		}
+	}
+
+	switch parseMultiValue {
+	case "":
+		parseMultiValue = "split"
+	case "split", "join":
+	default:
+		return nil, fmt.Errorf("invalid collectd multi value mode %q", parseMultiValue)
+	}
+
+	return &CollectdParser{
+		ParseMultiValue: parseMultiValue,
+		popts:           popts,
+	}, nil
+}
+
+func loadTypesDB(path string) (*api.TypesDB, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open collectd types.db %s: %s", path, err)
+	}
+	defer f.Close()
+	db, err := api.NewTypesDB(f)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse collectd types.db %s: %s", path, err)
+	}
+	return db, nil
+}
+
+// Parse parses a packet of the collectd network protocol. Value lists which
+// do not meet the security level are dropped by the decoder.
+func (p *CollectdParser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	opts := p.popts
+	// The signature covers the rest of the packet, which the decoder parses
+	// a second time as unsigned data. Only keep the verified value lists.
+	if len(buf) >= 2 && binary.BigEndian.Uint16(buf) == typeSignSHA256 &&
+		opts.SecurityLevel < network.Sign {
+		opts.SecurityLevel = network.Sign
+	}
+
+	valueLists, err := network.Parse(buf, opts)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse collectd packet: %s", err)
+	}
+
+	metrics := make([]telegraf.Metric, 0)
+	for _, vl := range valueLists {
+		metrics = append(metrics, p.unmarshalValueList(vl)...)
+	}
+	return metrics, nil
+}
+
+func (p *CollectdParser) ParseLine(line string) (telegraf.Metric, error) {
+	metrics, err := p.Parse([]byte(line))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(metrics) != 1 {
+		return nil, fmt.Errorf("Can not parse the line: %s, for data format: collectd", line)
+	}
+
+	return metrics[0], nil
+}
+
+func (p *CollectdParser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
+
+// unmarshalValueList converts a value list into metrics. In "split" mode,
+// every value becomes a metric named after the plugin and the data source,
+// with a single "value" field. In "join" mode, the values become the fields
+// of a metric named after the plugin.
+func (p *CollectdParser) unmarshalValueList(vl *api.ValueList) []telegraf.Metric {
+	tags := p.tags(vl)
+
+	if p.ParseMultiValue == "join" {
+		fields := make(map[string]interface{}, len(vl.Values))
+		for i, v := range vl.Values {
+			fields[vl.DSName(i)] = convert(v)
+		}
+		m, err := telegraf.NewTypedMetric(vl.Plugin, tags, fields, vl.Time, valueType(vl.Values))
+		if err != nil {
+			log.Printf("E! collectd: unable to create metric %s: %s\n", vl.Plugin, err)
+			return nil
+		}
+		return []telegraf.Metric{m}
+	}
+
+	metrics := make([]telegraf.Metric, 0, len(vl.Values))
+	for i, v := range vl.Values {
+		name := vl.Plugin
+		if ds := vl.DSName(i); ds != "value" {
+			name += "_" + ds
+		}
+		fields := map[string]interface{}{"value": convert(v)}
+		m, err := telegraf.NewTypedMetric(name, tags, fields, vl.Time, valueType(vl.Values[i:i+1]))
+		if err != nil {
+			log.Printf("E! collectd: unable to create metric %s: %s\n", name, err)
+			continue
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics
+}
+
+func (p *CollectdParser) tags(vl *api.ValueList) map[string]string {
+	tags := make(map[string]string)
+	for k, v := range p.DefaultTags {
+		tags[k] = v
+	}
+	if vl.Host != "" {
+		tags["host"] = vl.Host
+	}
+	if vl.PluginInstance != "" {
+		tags["instance"] = vl.PluginInstance
+	}
+	if vl.Type != "" {
+		tags["type"] = vl.Type
+	}
+	if vl.TypeInstance != "" {
+		tags["type_instance"] = vl.TypeInstance
+	}
+	return tags
+}
+
+// valueType returns gauge if all values are gauges, and counter if they are
+// all counters or derives.
+func valueType(values []api.Value) telegraf.ValueType {
+	gauges, counters := 0, 0
+	for _, v := range values {
+		if _, ok := v.(api.Gauge); ok {
+			gauges++
+		} else {
+			counters++
+		}
+	}
+	switch {
+	case gauges == len(values):
+		return telegraf.Gauge
+	case counters == len(values):
+		return telegraf.Counter
+	default:
+		return telegraf.Untyped
+	}
+}
+
+// convert returns gauges as floats and derives as integers. Counters are
+// unsigned and become floats if they do not fit an integer.
+func convert(v api.Value) interface{} {
+	switch v := v.(type) {
+	case api.Gauge:
+		return float64(v)
+	case api.Derive:
+		return int64(v)
+	case api.Counter:
+		if uint64(v) > math.MaxInt64 {
+			return float64(v)
+		}
+		return int64(v)
+	}
+	return nil
+}
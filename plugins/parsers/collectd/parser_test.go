@@ -0,0 +1,195 @@
+package collectd
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"collectd.org/api"
+	"collectd.org/network"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+)
+
+var testTime = time.Unix(1479822000, 0)
+
+var loadValueList = &api.ValueList{
+	Identifier: api.Identifier{
+		Host:   "xyzzy",
+		Plugin: "load",
+		Type:   "load",
+	},
+	Time:     testTime,
+	Interval: 10 * time.Second,
+	Values:   []api.Value{api.Gauge(0.5), api.Gauge(0.25), api.Gauge(0.1)},
+}
+
+var interfaceValueList = &api.ValueList{
+	Identifier: api.Identifier{
+		Host:           "xyzzy",
+		Plugin:         "interface",
+		PluginInstance: "eth0",
+		Type:           "if_octets",
+	},
+	Time:     testTime,
+	Interval: 10 * time.Second,
+	Values:   []api.Value{api.Derive(1024), api.Derive(2048)},
+}
+
+var cpuValueList = &api.ValueList{
+	Identifier: api.Identifier{
+		Host:           "xyzzy",
+		Plugin:         "cpu",
+		PluginInstance: "0",
+		Type:           "cpu",
+		TypeInstance:   "idle",
+	},
+	Time:     testTime,
+	Interval: 10 * time.Second,
+	Values:   []api.Value{api.Derive(42)},
+}
+
+const typesDB = `
+load      shortterm:GAUGE:0:5000, midterm:GAUGE:0:5000, longterm:GAUGE:0:5000
+if_octets rx:DERIVE:0:U, tx:DERIVE:0:U
+cpu       value:DERIVE:0:U
+`
+
+func writeTempFile(t *testing.T, content string) string {
+	f, err := ioutil.TempFile("", "collectd")
+	require.NoError(t, err)
+	_, err = f.WriteString(content)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	return f.Name()
+}
+
+func packet(t *testing.T, setup func(b *network.Buffer), vls ...*api.ValueList) []byte {
+	b := network.NewBuffer(0)
+	if setup != nil {
+		setup(b)
+	}
+	for _, vl := range vls {
+		require.NoError(t, b.Write(context.Background(), vl))
+	}
+	buf, err := b.Bytes()
+	require.NoError(t, err)
+	return buf
+}
+
+func TestParseSplit(t *testing.T) {
+	typesDBPath := writeTempFile(t, typesDB)
+	defer os.Remove(typesDBPath)
+
+	parser, err := NewCollectdParser("", "none", []string{typesDBPath}, "split")
+	require.NoError(t, err)
+	parser.SetDefaultTags(map[string]string{"source": "collectd"})
+
+	metrics, err := parser.Parse(packet(t, nil,
+		loadValueList, interfaceValueList, cpuValueList))
+	require.NoError(t, err)
+	require.Len(t, metrics, 6)
+
+	assert.Equal(t, "load_shortterm", metrics[0].Name())
+	assert.Equal(t, map[string]string{
+		"source": "collectd",
+		"host":   "xyzzy",
+		"type":   "load",
+	}, metrics[0].Tags())
+	assert.Equal(t, map[string]interface{}{"value": float64(0.5)}, metrics[0].Fields())
+	assert.Equal(t, telegraf.Gauge, metrics[0].Type())
+	assert.Equal(t, testTime.UnixNano(), metrics[0].UnixNano())
+	assert.Equal(t, "load_longterm", metrics[2].Name())
+
+	assert.Equal(t, "interface_tx", metrics[4].Name())
+	assert.Equal(t, map[string]string{
+		"source":   "collectd",
+		"host":     "xyzzy",
+		"instance": "eth0",
+		"type":     "if_octets",
+	}, metrics[4].Tags())
+	assert.Equal(t, map[string]interface{}{"value": int64(2048)}, metrics[4].Fields())
+	assert.Equal(t, telegraf.Counter, metrics[4].Type())
+
+	assert.Equal(t, "cpu", metrics[5].Name())
+	assert.Equal(t, "idle", metrics[5].Tags()["type_instance"])
+	assert.Equal(t, map[string]interface{}{"value": int64(42)}, metrics[5].Fields())
+}
+
+func TestParseJoin(t *testing.T) {
+	typesDBPath := writeTempFile(t, typesDB)
+	defer os.Remove(typesDBPath)
+
+	parser, err := NewCollectdParser("", "", []string{typesDBPath}, "join")
+	require.NoError(t, err)
+
+	metrics, err := parser.Parse(packet(t, nil, interfaceValueList))
+	require.NoError(t, err)
+	require.Len(t, metrics, 1)
+	assert.Equal(t, "interface", metrics[0].Name())
+	assert.Equal(t, map[string]interface{}{
+		"rx": int64(1024),
+		"tx": int64(2048),
+	}, metrics[0].Fields())
+}
+
+func TestParseWithoutTypesDB(t *testing.T) {
+	parser, err := NewCollectdParser("", "none", nil, "")
+	require.NoError(t, err)
+
+	metrics, err := parser.Parse(packet(t, nil, interfaceValueList))
+	require.NoError(t, err)
+	require.Len(t, metrics, 2)
+	assert.Equal(t, "interface_0", metrics[0].Name())
+	assert.Equal(t, "interface_1", metrics[1].Name())
+}
+
+func TestParseSecurityLevels(t *testing.T) {
+	authFile := writeTempFile(t, "alice: w0nderl4nd\n")
+	defer os.Remove(authFile)
+
+	signed := packet(t, func(b *network.Buffer) {
+		b.Sign("alice", "w0nderl4nd")
+	}, cpuValueList)
+	encrypted := packet(t, func(b *network.Buffer) {
+		b.Encrypt("alice", "w0nderl4nd")
+	}, cpuValueList)
+	plain := packet(t, nil, cpuValueList)
+	wrongPassword := packet(t, func(b *network.Buffer) {
+		b.Encrypt("alice", "wrong")
+	}, cpuValueList)
+
+	tests := []struct {
+		level string
+		buf   []byte
+		count int
+	}{
+		{"none", plain, 1},
+		{"none", signed, 1},
+		{"sign", plain, 0},
+		{"sign", signed, 1},
+		{"sign", encrypted, 1},
+		{"encrypt", signed, 0},
+		{"encrypt", encrypted, 1},
+		{"encrypt", wrongPassword, 0},
+	}
+	for _, tt := range tests {
+		parser, err := NewCollectdParser(authFile, tt.level, nil, "split")
+		require.NoError(t, err)
+		metrics, _ := parser.Parse(tt.buf)
+		assert.Len(t, metrics, tt.count, tt.level)
+	}
+}
+
+func TestInvalidOptions(t *testing.T) {
+	_, err := NewCollectdParser("", "paranoid", nil, "")
+	assert.Error(t, err)
+	_, err = NewCollectdParser("", "none", nil, "merge")
+	assert.Error(t, err)
+	_, err = NewCollectdParser("", "none", []string{"/nonexistent/types.db"}, "")
+	assert.Error(t, err)
+}
@@ -0,0 +1,281 @@
+package csv
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+)
+
+// errNoFields is returned for the rows which are empty or only hold tags,
+// as a metric needs at least one field.
+var errNoFields = errors.New("row has no fields")
+
+type Parser struct {
+	MetricName  string
+	DefaultTags map[string]string
+
+	// HeaderRowCount is the number of rows holding the column names. The
+	// names of several header rows are concatenated.
+	HeaderRowCount int
+	// SkipRows is the number of lines skipped before the header.
+	SkipRows int
+	// Delimiter separates the columns, "," by default.
+	Delimiter string
+	// Comment starts lines which are ignored.
+	Comment string
+	// TrimSpace removes the surrounding white space of every value.
+	TrimSpace bool
+
+	// ColumnNames are the names of the columns. They override the names from
+	// the header and are required when parsing single lines.
+	ColumnNames []string
+	// ColumnTypes are the types of the columns, one of "int", "float",
+	// "bool" or "string". Columns without a type are detected automatically.
+	ColumnTypes []string
+	// TagColumns are the columns added as tags instead of fields.
+	TagColumns []string
+	// MeasurementColumn is the column holding the measurement name.
+	MeasurementColumn string
+	// TimestampColumn is the column holding the metric time.
+	TimestampColumn string
+	// TimestampFormat is the layout of the timestamp as understood by
+	// time.Parse, or one of "unix", "unix_ms", "unix_us" or "unix_ns".
+	TimestampFormat string
+}
+
+func (p *Parser) newReader(r io.Reader) (*csv.Reader, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	if p.Delimiter != "" {
+		c, size := utf8.DecodeRuneInString(p.Delimiter)
+		if size != len(p.Delimiter) {
+			return nil, fmt.Errorf("csv delimiter must be a single character, got %q", p.Delimiter)
+		}
+		reader.Comma = c
+	}
+	if p.Comment != "" {
+		c, size := utf8.DecodeRuneInString(p.Comment)
+		if size != len(p.Comment) {
+			return nil, fmt.Errorf("csv comment must be a single character, got %q", p.Comment)
+		}
+		reader.Comment = c
+	}
+	return reader, nil
+}
+
+// Parse parses the rows following the skipped rows and the header into one
+// metric each.
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	r := bufio.NewReader(bytes.NewReader(buf))
+	for i := 0; i < p.SkipRows; i++ {
+		if _, err := r.ReadString('\n'); err != nil {
+			if err == io.EOF {
+				return nil, nil
+			}
+			return nil, err
+		}
+	}
+
+	reader, err := p.newReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var header []string
+	for i := 0; i < p.HeaderRowCount; i++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		for j, name := range record {
+			if p.TrimSpace {
+				name = strings.TrimSpace(name)
+			}
+			if j < len(header) {
+				header[j] += name
+			} else {
+				header = append(header, name)
+			}
+		}
+	}
+
+	columns := p.ColumnNames
+	if len(columns) == 0 {
+		columns = header
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("csv column names must be given by a header row or csv_column_names")
+	}
+
+	now := time.Now().UTC()
+	metrics := make([]telegraf.Metric, 0)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		metric, err := p.parseRecord(columns, record, now)
+		if err == errNoFields {
+			log.Printf("E! csv: skipping row without fields: %q\n", record)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, metric)
+	}
+	return metrics, nil
+}
+
+// ParseLine parses a single row. As the line carries no header, the column
+// names must be configured.
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	if len(p.ColumnNames) == 0 {
+		return nil, fmt.Errorf("csv column names must be configured to parse single lines")
+	}
+
+	reader, err := p.newReader(strings.NewReader(line))
+	if err != nil {
+		return nil, err
+	}
+	record, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("Can not parse the line: %s, for data format: csv", line)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p.parseRecord(p.ColumnNames, record, time.Now().UTC())
+}
+
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
+
+func (p *Parser) parseRecord(
+	columns []string,
+	record []string,
+	now time.Time,
+) (telegraf.Metric, error) {
+	tags := make(map[string]string)
+	for k, v := range p.DefaultTags {
+		tags[k] = v
+	}
+	fields := make(map[string]interface{})
+	name := p.MetricName
+	t := now
+
+	for i, value := range record {
+		if i >= len(columns) {
+			break
+		}
+		column := columns[i]
+		if column == "" {
+			continue
+		}
+		if p.TrimSpace {
+			value = strings.TrimSpace(value)
+		}
+
+		switch {
+		case column == p.MeasurementColumn:
+			if value != "" {
+				name = value
+			}
+			continue
+		case column == p.TimestampColumn:
+			// without a format, numeric timestamps are unix seconds
+			var v interface{} = value
+			if p.TimestampFormat == "" {
+				v, _ = convert(value, "")
+			}
+			ts, err := internal.ParseTimestamp(v, p.TimestampFormat)
+			if err != nil {
+				return nil, fmt.Errorf("csv column %s: %s", column, err)
+			}
+			t = ts
+			continue
+		case p.isTag(column):
+			if value != "" {
+				tags[column] = value
+			}
+			continue
+		}
+
+		if value == "" {
+			continue
+		}
+		var typ string
+		if i < len(p.ColumnTypes) {
+			typ = p.ColumnTypes[i]
+		}
+		v, err := convert(value, typ)
+		if err != nil {
+			return nil, fmt.Errorf("csv column %s: %s", column, err)
+		}
+		fields[column] = v
+	}
+
+	if len(fields) == 0 {
+		return nil, errNoFields
+	}
+	return telegraf.NewMetric(name, tags, fields, t)
+}
+
+func (p *Parser) isTag(column string) bool {
+	for _, tag := range p.TagColumns {
+		if tag == column {
+			return true
+		}
+	}
+	return false
+}
+
+// convert returns the value as the given type. Without a type, the value is
+// converted to the first of int, float and bool ("true" or "false") it is
+// valid for, or kept as a string.
+func convert(value string, typ string) (interface{}, error) {
+	switch typ {
+	case "int", "integer":
+		return strconv.ParseInt(value, 10, 64)
+	case "float":
+		return strconv.ParseFloat(value, 64)
+	case "bool", "boolean":
+		return strconv.ParseBool(value)
+	case "str", "string":
+		return value, nil
+	case "":
+		if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return i, nil
+		}
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f, nil
+		}
+		switch strings.ToLower(value) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+		return value, nil
+	default:
+		return nil, fmt.Errorf("invalid column type %q", typ)
+	}
+}
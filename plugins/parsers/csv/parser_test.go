@@ -0,0 +1,177 @@
+package csv
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const validCSV = `# exported by sensord
+host,temperature,humidity,ok
+a,21.5,40,true
+b,22,,false
+`
+
+func TestParseHeader(t *testing.T) {
+	parser := Parser{
+		MetricName:     "sensor",
+		HeaderRowCount: 1,
+		Comment:        "#",
+		TagColumns:     []string{"host"},
+	}
+	metrics, err := parser.Parse([]byte(validCSV))
+	require.NoError(t, err)
+	require.Len(t, metrics, 2)
+
+	assert.Equal(t, "sensor", metrics[0].Name())
+	assert.Equal(t, map[string]string{"host": "a"}, metrics[0].Tags())
+	assert.Equal(t, map[string]interface{}{
+		"temperature": float64(21.5),
+		"humidity":    int64(40),
+		"ok":          true,
+	}, metrics[0].Fields())
+
+	assert.Equal(t, map[string]string{"host": "b"}, metrics[1].Tags())
+	assert.Equal(t, map[string]interface{}{
+		"temperature": int64(22),
+		"ok":          false,
+	}, metrics[1].Fields())
+}
+
+func TestParseColumnTypes(t *testing.T) {
+	parser := Parser{
+		MetricName:     "sensor",
+		HeaderRowCount: 1,
+		Comment:        "#",
+		ColumnTypes:    []string{"string", "float", "float", "string"},
+	}
+	metrics, err := parser.Parse([]byte(validCSV))
+	require.NoError(t, err)
+	require.Len(t, metrics, 2)
+	assert.Equal(t, map[string]interface{}{
+		"host":        "b",
+		"temperature": float64(22),
+		"ok":          "false",
+	}, metrics[1].Fields())
+
+	parser.ColumnTypes = []string{"string", "int"}
+	_, err = parser.Parse([]byte(validCSV))
+	assert.Error(t, err)
+}
+
+func TestParseSkipRowsAndMultipleHeaders(t *testing.T) {
+	parser := Parser{
+		MetricName:     "sensor",
+		SkipRows:       2,
+		HeaderRowCount: 2,
+		Delimiter:      ";",
+		TrimSpace:      true,
+	}
+	data := "sensord export\nversion 2\ntemp; temp\n_in; _out\n 20.5 ; 3\n"
+	metrics, err := parser.Parse([]byte(data))
+	require.NoError(t, err)
+	require.Len(t, metrics, 1)
+	assert.Equal(t, map[string]interface{}{
+		"temp_in":  float64(20.5),
+		"temp_out": int64(3),
+	}, metrics[0].Fields())
+}
+
+func TestParseColumnNames(t *testing.T) {
+	parser := Parser{
+		MetricName:     "sensor",
+		HeaderRowCount: 1,
+		Comment:        "#",
+		ColumnNames:    []string{"", "temp"},
+	}
+	metrics, err := parser.Parse([]byte(validCSV))
+	require.NoError(t, err)
+	require.Len(t, metrics, 2)
+	assert.Equal(t, map[string]interface{}{"temp": float64(21.5)}, metrics[0].Fields())
+	assert.Empty(t, metrics[0].Tags())
+}
+
+func TestParseMeasurementAndTimestamp(t *testing.T) {
+	parser := Parser{
+		MetricName:        "csv",
+		ColumnNames:       []string{"name", "time", "value"},
+		MeasurementColumn: "name",
+		TimestampColumn:   "time",
+		TimestampFormat:   "2006-01-02 15:04:05",
+		DefaultTags:       map[string]string{"source": "export"},
+	}
+	metrics, err := parser.Parse([]byte("cpu,2016-11-22 13:40:00,5\n"))
+	require.NoError(t, err)
+	require.Len(t, metrics, 1)
+	assert.Equal(t, "cpu", metrics[0].Name())
+	assert.Equal(t, map[string]string{"source": "export"}, metrics[0].Tags())
+	assert.Equal(t, map[string]interface{}{"value": int64(5)}, metrics[0].Fields())
+	assert.Equal(t, time.Date(2016, 11, 22, 13, 40, 0, 0, time.UTC), metrics[0].Time())
+
+	parser.TimestampFormat = ""
+	metrics, err = parser.Parse([]byte("cpu,1479822000,5\n"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1479822000000000000), metrics[0].UnixNano())
+
+	parser.TimestampFormat = "unix_ms"
+	metrics, err = parser.Parse([]byte("cpu,1479822000123,5\n"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1479822000123000000), metrics[0].UnixNano())
+
+	_, err = parser.Parse([]byte("cpu,yesterday,5\n"))
+	assert.Error(t, err)
+}
+
+func TestParseLine(t *testing.T) {
+	parser := Parser{
+		MetricName:  "sensor",
+		ColumnNames: []string{"host", "temperature"},
+		TagColumns:  []string{"host"},
+	}
+	metric, err := parser.ParseLine(`"a",21.5`)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"host": "a"}, metric.Tags())
+	assert.Equal(t, map[string]interface{}{"temperature": float64(21.5)}, metric.Fields())
+
+	parser.ColumnNames = nil
+	_, err = parser.ParseLine("a,21.5")
+	assert.Error(t, err)
+}
+
+func TestParseNoColumns(t *testing.T) {
+	parser := Parser{MetricName: "sensor"}
+	_, err := parser.Parse([]byte("a,1\n"))
+	assert.Error(t, err)
+
+	parser.HeaderRowCount = 1
+	metrics, err := parser.Parse([]byte(""))
+	assert.NoError(t, err)
+	assert.Empty(t, metrics)
+}
+
+func TestParseSkipRowsWithoutFields(t *testing.T) {
+	parser := Parser{
+		MetricName:     "sensor",
+		HeaderRowCount: 1,
+		TagColumns:     []string{"host"},
+	}
+	metrics, err := parser.Parse([]byte(`host,temperature,humidity
+a,21.5,40
+,,
+b,,
+c,22,
+`))
+	require.NoError(t, err)
+	require.Len(t, metrics, 2)
+	assert.Equal(t, map[string]string{"host": "a"}, metrics[0].Tags())
+	assert.Equal(t, map[string]string{"host": "c"}, metrics[1].Tags())
+	assert.Equal(t, map[string]interface{}{
+		"temperature": int64(22),
+	}, metrics[1].Fields())
+
+	parser.ColumnNames = []string{"host", "temperature", "humidity"}
+	_, err = parser.ParseLine("b,,")
+	assert.Error(t, err)
+}
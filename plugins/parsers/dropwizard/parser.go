@@ -0,0 +1,222 @@
+package dropwizard
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/parsers/graphite"
+)
+
+// sections are the sections of a metric registry, with the metric_type tag
+// and value type of their metrics.
+var sections = []struct {
+	key        string
+	metricType string
+	valueType  telegraf.ValueType
+}{
+	{"counters", "counter", telegraf.Counter},
+	{"meters", "meter", telegraf.Counter},
+	{"gauges", "gauge", telegraf.Gauge},
+	{"histograms", "histogram", telegraf.Gauge},
+	{"timers", "timer", telegraf.Gauge},
+}
+
+type Parser struct {
+	DefaultTags map[string]string
+
+	// RegistryPath is the dotted path of the metric registry within the
+	// document, ie "metrics". By default, the document is the registry.
+	RegistryPath string
+	// TimePath is the dotted path of the time within the document.
+	TimePath string
+	// TimeFormat is the layout of the time as understood by time.Parse, or
+	// one of "unix", "unix_ms", "unix_us" or "unix_ns".
+	TimeFormat string
+
+	// Separator and Templates configure the graphite templates extracting
+	// the measurement name and tags from the dotted metric names. Without
+	// templates, the metric names are used as measurement names.
+	Separator string
+	Templates []string
+
+	templateEngine *graphite.GraphiteParser
+}
+
+// NewParser returns a dropwizard parser, validating its templates.
+func NewParser(
+	separator string,
+	templates []string,
+	defaultTags map[string]string,
+) (*Parser, error) {
+	p := &Parser{
+		Separator:   separator,
+		Templates:   templates,
+		DefaultTags: defaultTags,
+	}
+	if len(templates) > 0 {
+		var err error
+		p.templateEngine, err = graphite.NewGraphiteParser(separator, templates, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// Parse parses a metric registry into a metric per registered metric.
+// Numbers are decoded as json.Number so that counts keep their precision.
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	var doc map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(buf))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("unable to parse dropwizard metrics as JSON, %s", err)
+	}
+
+	t := time.Now().UTC()
+	if p.TimePath != "" {
+		v, err := lookup(doc, p.TimePath)
+		if err != nil {
+			return nil, err
+		}
+		if n, ok := v.(json.Number); ok {
+			if i, err := n.Int64(); err == nil {
+				v = i
+			} else if f, err := n.Float64(); err == nil {
+				v = f
+			}
+		}
+		if t, err = internal.ParseTimestamp(v, p.TimeFormat); err != nil {
+			return nil, fmt.Errorf("dropwizard time %s: %s", p.TimePath, err)
+		}
+	}
+
+	registry := doc
+	if p.RegistryPath != "" {
+		v, err := lookup(doc, p.RegistryPath)
+		if err != nil {
+			return nil, err
+		}
+		var ok bool
+		if registry, ok = v.(map[string]interface{}); !ok {
+			return nil, fmt.Errorf("dropwizard registry %s is not an object", p.RegistryPath)
+		}
+	}
+
+	metrics := make([]telegraf.Metric, 0)
+	for _, section := range sections {
+		entries, ok := registry[section.key].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for name, entry := range entries {
+			values, ok := entry.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			m, err := p.newMetric(name, values, section.metricType, section.valueType, t)
+			if err != nil {
+				log.Printf("E! dropwizard: unable to parse metric %s: %s\n", name, err)
+				continue
+			}
+			if m != nil {
+				metrics = append(metrics, m)
+			}
+		}
+	}
+	return metrics, nil
+}
+
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	metrics, err := p.Parse([]byte(line + "\n"))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(metrics) < 1 {
+		return nil, fmt.Errorf("Can not parse the line: %s, for data format: dropwizard", line)
+	}
+
+	return metrics[0], nil
+}
+
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
+
+// newMetric returns the metric of a registered metric, or nil if it has no
+// fields. Numbers, strings and booleans become fields, nested values are
+// skipped. The counts of counters, meters, histograms and timers are
+// integers, all other numbers are floats.
+func (p *Parser) newMetric(
+	name string,
+	values map[string]interface{},
+	metricType string,
+	valueType telegraf.ValueType,
+	t time.Time,
+) (telegraf.Metric, error) {
+	measurement := name
+	tags := make(map[string]string)
+	if p.templateEngine != nil {
+		var err error
+		measurement, tags, _, err = p.templateEngine.ApplyTemplate(name)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for k, v := range p.DefaultTags {
+		if _, ok := tags[k]; !ok {
+			tags[k] = v
+		}
+	}
+	tags["metric_type"] = metricType
+
+	fields := make(map[string]interface{})
+	for k, v := range values {
+		switch v := v.(type) {
+		case json.Number:
+			if k == "count" {
+				if i, err := v.Int64(); err == nil {
+					fields[k] = i
+					continue
+				}
+			}
+			if f, err := v.Float64(); err == nil {
+				fields[k] = f
+			}
+		case string, bool:
+			fields[k] = v
+		}
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	switch valueType {
+	case telegraf.Counter:
+		return telegraf.NewCounterMetric(measurement, tags, fields, t)
+	default:
+		return telegraf.NewGaugeMetric(measurement, tags, fields, t)
+	}
+}
+
+// lookup returns the value at the dotted path of object keys.
+func lookup(doc map[string]interface{}, path string) (interface{}, error) {
+	var v interface{} = doc
+	for _, key := range strings.Split(path, ".") {
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("dropwizard path %s: %s is not an object", path, key)
+		}
+		if v, ok = obj[key]; !ok {
+			return nil, fmt.Errorf("dropwizard path %s: key %s not found", path, key)
+		}
+	}
+	return v, nil
+}
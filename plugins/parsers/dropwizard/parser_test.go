@@ -0,0 +1,151 @@
+package dropwizard
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+)
+
+const validRegistry = `
+{
+  "version": "3.0.0",
+  "gauges": {
+    "jvm.memory.heap.used": {"value": 1048576},
+    "jvm.attribute.name": {"value": "12345@host"},
+    "jvm.threads.deadlocks": {"value": []}
+  },
+  "counters": {
+    "web.requests.active": {"count": 3}
+  },
+  "histograms": {
+    "db.rows": {"count": 2, "max": 10, "mean": 7.5, "min": 5, "p50": 5, "p99": 10, "stddev": 2.5}
+  },
+  "meters": {
+    "web.requests.errors": {"count": 4, "m1_rate": 0.5, "mean_rate": 0.1, "units": "events/second"}
+  },
+  "timers": {
+    "web.requests.latency": {"count": 2, "max": 0.2, "min": 0.1, "p95": 0.2, "duration_units": "seconds", "rate_units": "calls/second"}
+  }
+}
+`
+
+func find(metrics []telegraf.Metric, name string) telegraf.Metric {
+	for _, m := range metrics {
+		if m.Name() == name {
+			return m
+		}
+	}
+	return nil
+}
+
+func TestParseRegistry(t *testing.T) {
+	parser, err := NewParser("", nil, map[string]string{"host": "a"})
+	require.NoError(t, err)
+
+	metrics, err := parser.Parse([]byte(validRegistry))
+	require.NoError(t, err)
+	require.Len(t, metrics, 6)
+
+	m := find(metrics, "jvm.memory.heap.used")
+	require.NotNil(t, m)
+	assert.Equal(t, telegraf.Gauge, m.Type())
+	assert.Equal(t, map[string]string{"host": "a", "metric_type": "gauge"}, m.Tags())
+	assert.Equal(t, map[string]interface{}{"value": float64(1048576)}, m.Fields())
+
+	m = find(metrics, "jvm.attribute.name")
+	require.NotNil(t, m)
+	assert.Equal(t, map[string]interface{}{"value": "12345@host"}, m.Fields())
+
+	m = find(metrics, "web.requests.active")
+	require.NotNil(t, m)
+	assert.Equal(t, telegraf.Counter, m.Type())
+	assert.Equal(t, "counter", m.Tags()["metric_type"])
+	assert.Equal(t, map[string]interface{}{"count": int64(3)}, m.Fields())
+
+	m = find(metrics, "web.requests.errors")
+	require.NotNil(t, m)
+	assert.Equal(t, telegraf.Counter, m.Type())
+	assert.Equal(t, "meter", m.Tags()["metric_type"])
+	assert.Equal(t, "events/second", m.Fields()["units"])
+
+	m = find(metrics, "db.rows")
+	require.NotNil(t, m)
+	assert.Equal(t, telegraf.Gauge, m.Type())
+	assert.Equal(t, "histogram", m.Tags()["metric_type"])
+	assert.Equal(t, float64(7.5), m.Fields()["mean"])
+	assert.Equal(t, int64(2), m.Fields()["count"])
+	assert.Equal(t, float64(10), m.Fields()["max"])
+
+	m = find(metrics, "web.requests.latency")
+	require.NotNil(t, m)
+	assert.Equal(t, "timer", m.Tags()["metric_type"])
+	assert.Equal(t, float64(0.2), m.Fields()["p95"])
+}
+
+func TestParseLargeCount(t *testing.T) {
+	parser, err := NewParser("", nil, nil)
+	require.NoError(t, err)
+
+	metrics, err := parser.Parse([]byte(`{"counters": {"requests": {"count": 9007199254740993}}}`))
+	require.NoError(t, err)
+	require.Len(t, metrics, 1)
+	assert.Equal(t, map[string]interface{}{"count": int64(9007199254740993)},
+		metrics[0].Fields())
+}
+
+func TestParseTemplates(t *testing.T) {
+	parser, err := NewParser("_", []string{
+		"jvm.* measurement.measurement.area.measurement",
+		"web.* app.measurement.measurement",
+	}, nil)
+	require.NoError(t, err)
+
+	metrics, err := parser.Parse([]byte(validRegistry))
+	require.NoError(t, err)
+
+	m := find(metrics, "jvm_memory_used")
+	require.NotNil(t, m)
+	assert.Equal(t, map[string]string{"area": "heap", "metric_type": "gauge"}, m.Tags())
+
+	m = find(metrics, "requests_latency")
+	require.NotNil(t, m)
+	assert.Equal(t, map[string]string{"app": "web", "metric_type": "timer"}, m.Tags())
+
+	// names not matching a template keep the default graphite template
+	assert.NotNil(t, find(metrics, "db_rows"))
+}
+
+func TestParseRegistryAndTimePath(t *testing.T) {
+	parser, err := NewParser("", nil, nil)
+	require.NoError(t, err)
+	parser.RegistryPath = "metrics"
+	parser.TimePath = "time"
+	parser.TimeFormat = "2006-01-02T15:04:05Z07:00"
+
+	metrics, err := parser.Parse([]byte(`
+{
+  "time": "2016-11-22T13:40:00Z",
+  "metrics": {"counters": {"requests": {"count": 1}}}
+}`))
+	require.NoError(t, err)
+	require.Len(t, metrics, 1)
+	assert.Equal(t, time.Date(2016, 11, 22, 13, 40, 0, 0, time.UTC), metrics[0].Time())
+
+	parser.RegistryPath = "registry"
+	_, err = parser.Parse([]byte(`{"time": "2016-11-22T13:40:00Z"}`))
+	assert.Error(t, err)
+}
+
+func TestParseInvalid(t *testing.T) {
+	parser, err := NewParser("", nil, nil)
+	require.NoError(t, err)
+	_, err = parser.Parse([]byte(`not json`))
+	assert.Error(t, err)
+
+	_, err = NewParser("", []string{"host.region"}, nil)
+	assert.Error(t, err)
+}
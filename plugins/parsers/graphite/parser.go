@@ -26,6 +26,10 @@ type GraphiteParser struct {
 	Templates   []string
 	DefaultTags map[string]string
 
+	// TagSupport parses the tags of tagged series ("name;tag=value"). They
+	// take precedence over the tags extracted by the templates.
+	TagSupport bool
+
 	matcher *matcher
 }
 
@@ -161,15 +165,19 @@ func (p *GraphiteParser) ParseLine(line string) (telegraf.Metric, error) {
 	}
 
 	// decode the name and tags
-	template := p.matcher.Match(fields[0])
-	measurement, tags, field, err := template.Apply(fields[0])
+	name, seriesTags := p.splitTags(fields[0])
+	template := p.matcher.Match(name)
+	measurement, tags, field, err := template.Apply(name)
 	if err != nil {
 		return nil, err
 	}
+	for k, v := range seriesTags {
+		tags[k] = v
+	}
 
 	// Could not extract measurement, use the raw value
 	if measurement == "" {
-		measurement = fields[0]
+		measurement = name
 	}
 
 	// Parse value.
@@ -228,8 +236,15 @@ func (p *GraphiteParser) ApplyTemplate(line string) (string, map[string]string,
 		return "", make(map[string]string), "", nil
 	}
 	// decode the name and tags
-	template := p.matcher.Match(fields[0])
-	name, tags, field, err := template.Apply(fields[0])
+	name, seriesTags := p.splitTags(fields[0])
+	template := p.matcher.Match(name)
+	name, tags, field, err := template.Apply(name)
+	if err != nil {
+		return name, tags, field, err
+	}
+	for k, v := range seriesTags {
+		tags[k] = v
+	}
 
 	// Set the default tags on the point if they are not already set
 	for k, v := range p.DefaultTags {
@@ -241,6 +256,28 @@ func (p *GraphiteParser) ApplyTemplate(line string) (string, map[string]string,
 	return name, tags, field, err
 }
 
+// splitTags splits a tagged series into its name and tags if tag support is
+// enabled. Tags without a key or value are skipped.
+func (p *GraphiteParser) splitTags(series string) (string, map[string]string) {
+	if !p.TagSupport {
+		return series, nil
+	}
+	parts := strings.Split(series, ";")
+	if len(parts) == 1 {
+		return series, nil
+	}
+
+	tags := make(map[string]string, len(parts)-1)
+	for _, part := range parts[1:] {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+			continue
+		}
+		tags[kv[0]] = kv[1]
+	}
+	return parts[0], tags
+}
+
 // template represents a pattern and tags to map a graphite metric string to a influxdb Point
 type template struct {
 	tags              []string
@@ -789,6 +789,36 @@ func TestApplyTemplateMostSpecificTemplate(t *testing.T) {
 		tags)
 }
 
+func TestParseTaggedSeries(t *testing.T) {
+	p, err := NewGraphiteParser("_",
+		[]string{"cpu.* measurement.field"},
+		map[string]string{"dc": "default", "host": "default"})
+	assert.NoError(t, err)
+	p.TagSupport = true
+
+	m, err := p.ParseLine("cpu.usage_idle;host=server01;region=us-west;=x;empty= 91.5 1479822000")
+	assert.NoError(t, err)
+	assert.Equal(t, "cpu", m.Name())
+	assert.Equal(t, map[string]string{
+		"dc":     "default",
+		"host":   "server01",
+		"region": "us-west",
+	}, m.Tags())
+	assert.Equal(t, map[string]interface{}{"usage_idle": float64(91.5)}, m.Fields())
+
+	measurement, tags, field, err := p.ApplyTemplate("cpu.usage_user;host=server02")
+	assert.NoError(t, err)
+	assert.Equal(t, "cpu", measurement)
+	assert.Equal(t, "usage_user", field)
+	assert.Equal(t, "server02", tags["host"])
+
+	// without tag support, the tags stay part of the name
+	p.TagSupport = false
+	m, err = p.ParseLine("mem;host=server01 10")
+	assert.NoError(t, err)
+	assert.Equal(t, "mem;host=server01", m.Name())
+}
+
 // Test Helpers
 func errstr(err error) string {
 	if err != nil {
@@ -1,6 +1,7 @@
 package json
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"strconv"
@@ -8,24 +9,89 @@ import (
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal"
 )
 
 type JSONParser struct {
 	MetricName  string
 	TagKeys     []string
 	DefaultTags map[string]string
+
+	// Query selects the part of the document to parse, as a path of object
+	// keys and array indexes separated by dots, ie "data.items".
+	Query string
+	// StringFields are the string fields to keep, glob patterns are supported.
+	StringFields []string
+	// NameKey is the key of the field holding the measurement name.
+	NameKey string
+	// TimeKey is the key of the field holding the timestamp.
+	TimeKey string
+	// TimeFormat is the layout of the timestamp as understood by time.Parse,
+	// or one of "unix", "unix_ms", "unix_us" or "unix_ns" for numeric epochs.
+	TimeFormat string
+
+	stringFields filter.Filter
 }
 
+// Parse parses a JSON object into a single metric, or an array of JSON
+// objects into one metric per element. Numbers are decoded as json.Number so
+// that integer timestamps keep their precision.
 func (p *JSONParser) Parse(buf []byte) ([]telegraf.Metric, error) {
-	metrics := make([]telegraf.Metric, 0)
-
-	var jsonOut map[string]interface{}
-	err := json.Unmarshal(buf, &jsonOut)
+	var doc interface{}
+	dec := json.NewDecoder(bytes.NewReader(buf))
+	dec.UseNumber()
+	err := dec.Decode(&doc)
 	if err != nil {
 		err = fmt.Errorf("unable to parse out as JSON, %s", err)
 		return nil, err
 	}
 
+	if p.stringFields == nil && len(p.StringFields) > 0 {
+		p.stringFields, err = filter.Compile(p.StringFields)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if p.Query != "" {
+		doc, err = query(doc, p.Query)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now().UTC()
+	switch v := doc.(type) {
+	case map[string]interface{}:
+		metric, err := p.parseObject(v, now)
+		if err != nil {
+			return nil, err
+		}
+		return []telegraf.Metric{metric}, nil
+	case []interface{}:
+		metrics := make([]telegraf.Metric, 0, len(v))
+		for _, elem := range v {
+			obj, ok := elem.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("JSON array elements must be objects, got %T", elem)
+			}
+			metric, err := p.parseObject(obj, now)
+			if err != nil {
+				return nil, err
+			}
+			metrics = append(metrics, metric)
+		}
+		return metrics, nil
+	default:
+		return nil, fmt.Errorf("JSON must be an object or an array of objects, got %T", doc)
+	}
+}
+
+func (p *JSONParser) parseObject(
+	jsonOut map[string]interface{},
+	now time.Time,
+) (telegraf.Metric, error) {
 	tags := make(map[string]string)
 	for k, v := range p.DefaultTags {
 		tags[k] = v
@@ -39,22 +105,75 @@ func (p *JSONParser) Parse(buf []byte) ([]telegraf.Metric, error) {
 			tags[tag] = strconv.FormatBool(v)
 		case float64:
 			tags[tag] = strconv.FormatFloat(v, 'f', -1, 64)
+		case json.Number:
+			tags[tag] = v.String()
 		}
 		delete(jsonOut, tag)
 	}
 
+	name := p.MetricName
+	if p.NameKey != "" {
+		if v, ok := jsonOut[p.NameKey].(string); ok && v != "" {
+			name = v
+		}
+		delete(jsonOut, p.NameKey)
+	}
+
+	t := now
+	if p.TimeKey != "" {
+		v, ok := jsonOut[p.TimeKey]
+		if !ok {
+			return nil, fmt.Errorf("JSON time key %q not found", p.TimeKey)
+		}
+		if n, ok := v.(json.Number); ok {
+			if i, err := n.Int64(); err == nil {
+				v = i
+			} else if f, err := n.Float64(); err == nil {
+				v = f
+			}
+		}
+		var err error
+		t, err = internal.ParseTimestamp(v, p.TimeFormat)
+		if err != nil {
+			return nil, err
+		}
+		delete(jsonOut, p.TimeKey)
+	}
+
 	f := JSONFlattener{}
-	err = f.FlattenJSON("", jsonOut)
+	err := f.FullFlattenJSON("", jsonOut, p.stringFields != nil)
 	if err != nil {
 		return nil, err
 	}
+	for k, v := range f.Fields {
+		if _, ok := v.(string); ok && (p.stringFields == nil || !p.stringFields.Match(k)) {
+			delete(f.Fields, k)
+		}
+	}
 
-	metric, err := telegraf.NewMetric(p.MetricName, tags, f.Fields, time.Now().UTC())
+	return telegraf.NewMetric(name, tags, f.Fields, t)
+}
 
-	if err != nil {
-		return nil, err
+// query returns the part of the document selected by the dotted path.
+func query(doc interface{}, path string) (interface{}, error) {
+	for _, key := range strings.Split(path, ".") {
+		switch v := doc.(type) {
+		case map[string]interface{}:
+			var ok bool
+			if doc, ok = v[key]; !ok {
+				return nil, fmt.Errorf("JSON query %q: key %q not found", path, key)
+			}
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, fmt.Errorf("JSON query %q: invalid array index %q", path, key)
+			}
+			doc = v[i]
+		default:
+			return nil, fmt.Errorf("JSON query %q: %q is not an object or array", path, key)
+		}
 	}
-	return append(metrics, metric), nil
+	return doc, nil
 }
 
 func (p *JSONParser) ParseLine(line string) (telegraf.Metric, error) {
@@ -83,6 +202,16 @@ type JSONFlattener struct {
 func (f *JSONFlattener) FlattenJSON(
 	fieldname string,
 	v interface{},
+) error {
+	return f.FullFlattenJSON(fieldname, v, false)
+}
+
+// FullFlattenJSON flattens nested maps/interfaces into a fields map,
+// keeping string values if convertString is true.
+func (f *JSONFlattener) FullFlattenJSON(
+	fieldname string,
+	v interface{},
+	convertString bool,
 ) error {
 	if f.Fields == nil {
 		f.Fields = make(map[string]interface{})
@@ -91,7 +220,7 @@ func (f *JSONFlattener) FlattenJSON(
 	switch t := v.(type) {
 	case map[string]interface{}:
 		for k, v := range t {
-			err := f.FlattenJSON(fieldname+"_"+k+"_", v)
+			err := f.FullFlattenJSON(fieldname+"_"+k+"_", v, convertString)
 			if err != nil {
 				return err
 			}
@@ -99,14 +228,25 @@ func (f *JSONFlattener) FlattenJSON(
 	case []interface{}:
 		for i, v := range t {
 			k := strconv.Itoa(i)
-			err := f.FlattenJSON(fieldname+"_"+k+"_", v)
+			err := f.FullFlattenJSON(fieldname+"_"+k+"_", v, convertString)
 			if err != nil {
 				return nil
 			}
 		}
 	case float64:
 		f.Fields[fieldname] = t
-	case bool, string, nil:
+	case json.Number:
+		// numbers are always floats, so that fields keep a single type
+		v, err := t.Float64()
+		if err != nil {
+			return fmt.Errorf("JSON Flattener: invalid number %s (%s)", t, fieldname)
+		}
+		f.Fields[fieldname] = v
+	case string:
+		if convertString {
+			f.Fields[fieldname] = t
+		}
+	case bool, nil:
 		// ignored types
 		return nil
 	default:
@@ -282,3 +282,162 @@ func TestParseValidJSONDefaultTagsOverride(t *testing.T) {
 		"mytag": "foobar",
 	}, metrics[0].Tags())
 }
+
+const validJSONFeed = `
+{
+    "status": "ok",
+    "data": {
+        "items": [
+            {
+                "sensor": "temp",
+                "location": "attic",
+                "value": 21.5,
+                "state": "ok",
+                "ts": 1479822000
+            },
+            {
+                "sensor": "humidity",
+                "location": "cellar",
+                "value": 80,
+                "state": "high",
+                "ts": 1479822001.5
+            }
+        ]
+    }
+}
+`
+
+// Test that a queried array is parsed into one metric per element
+func TestParseJSONQueryArray(t *testing.T) {
+	parser := JSONParser{
+		MetricName:   "json_test",
+		TagKeys:      []string{"location"},
+		Query:        "data.items",
+		StringFields: []string{"state"},
+		NameKey:      "sensor",
+		TimeKey:      "ts",
+		TimeFormat:   "unix",
+	}
+
+	metrics, err := parser.Parse([]byte(validJSONFeed))
+	assert.NoError(t, err)
+	assert.Len(t, metrics, 2)
+
+	assert.Equal(t, "temp", metrics[0].Name())
+	assert.Equal(t, map[string]interface{}{
+		"value": float64(21.5),
+		"state": "ok",
+	}, metrics[0].Fields())
+	assert.Equal(t, map[string]string{"location": "attic"}, metrics[0].Tags())
+	assert.Equal(t, int64(1479822000000000000), metrics[0].UnixNano())
+
+	assert.Equal(t, "humidity", metrics[1].Name())
+	assert.Equal(t, map[string]string{"location": "cellar"}, metrics[1].Tags())
+	assert.Equal(t, int64(1479822001500000000), metrics[1].UnixNano())
+}
+
+// Test that a query can index into arrays
+func TestParseJSONQueryIndex(t *testing.T) {
+	parser := JSONParser{
+		MetricName: "json_test",
+		Query:      "data.items.1",
+	}
+
+	metrics, err := parser.Parse([]byte(validJSONFeed))
+	assert.NoError(t, err)
+	assert.Len(t, metrics, 1)
+	assert.Equal(t, "json_test", metrics[0].Name())
+	assert.Equal(t, map[string]interface{}{
+		"value": float64(80),
+		"ts":    float64(1479822001.5),
+	}, metrics[0].Fields())
+
+	for _, q := range []string{"data.missing", "data.items.2", "status.x"} {
+		parser.Query = q
+		_, err = parser.Parse([]byte(validJSONFeed))
+		assert.Error(t, err, q)
+	}
+}
+
+// Test that a top-level array is parsed into one metric per element
+func TestParseJSONArray(t *testing.T) {
+	parser := JSONParser{
+		MetricName: "json_test",
+	}
+
+	metrics, err := parser.Parse([]byte(`[{"a": 1}, {"a": 2}]`))
+	assert.NoError(t, err)
+	assert.Len(t, metrics, 2)
+	assert.Equal(t, map[string]interface{}{"a": float64(2)}, metrics[1].Fields())
+
+	_, err = parser.Parse([]byte(`[1, 2]`))
+	assert.Error(t, err)
+}
+
+// Test that string fields are selected with globs
+func TestParseJSONStringFields(t *testing.T) {
+	parser := JSONParser{
+		MetricName:   "json_test",
+		StringFields: []string{"b_*"},
+	}
+
+	metrics, err := parser.Parse([]byte(`{"a": "x", "b": {"c": "y", "d": 1}}`))
+	assert.NoError(t, err)
+	assert.Len(t, metrics, 1)
+	assert.Equal(t, map[string]interface{}{
+		"b_c": "y",
+		"b_d": float64(1),
+	}, metrics[0].Fields())
+}
+
+func TestParseJSONTimeFormats(t *testing.T) {
+	tests := []struct {
+		value    string
+		format   string
+		expected int64
+	}{
+		{`1479822000`, "", 1479822000000000000},
+		{`"2016-11-22T13:40:00Z"`, "", 1479822000000000000},
+		{`"22/11/2016 13:40"`, "02/01/2006 15:04", 1479822000000000000},
+		{`1479822000123`, "unix_ms", 1479822000123000000},
+		{`"1479822000123456"`, "unix_us", 1479822000123456000},
+		{`1479822000123456789`, "unix_ns", 1479822000123456789},
+		{`1479822000.5`, "unix", 1479822000500000000},
+	}
+
+	for _, test := range tests {
+		parser := JSONParser{
+			MetricName: "json_test",
+			TimeKey:    "time",
+			TimeFormat: test.format,
+		}
+		metrics, err := parser.Parse([]byte(`{"a": 1, "time": ` + test.value + `}`))
+		assert.NoError(t, err, test.value)
+		assert.Len(t, metrics, 1)
+		assert.Equal(t, test.expected, metrics[0].UnixNano(), test.value)
+		assert.Equal(t, map[string]interface{}{"a": float64(1)}, metrics[0].Fields())
+	}
+
+	parser := JSONParser{
+		MetricName: "json_test",
+		TimeKey:    "time",
+	}
+	_, err := parser.Parse([]byte(`{"a": 1}`))
+	assert.Error(t, err)
+	_, err = parser.Parse([]byte(`{"a": 1, "time": "yesterday"}`))
+	assert.Error(t, err)
+}
+
+func TestParseJSONNumbersAreFloats(t *testing.T) {
+	parser := JSONParser{
+		MetricName: "json_test",
+	}
+	metrics, err := parser.Parse([]byte(`{"small": 42, "large": 9007199254740993, "float": 1.5}`))
+	assert.NoError(t, err)
+	assert.Len(t, metrics, 1)
+	assert.Equal(t, map[string]interface{}{
+		"small": float64(42),
+		"large": float64(9007199254740993),
+		"float": float64(1.5),
+	}, metrics[0].Fields())
+}
@@ -0,0 +1,203 @@
+package logfmt
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+)
+
+type Parser struct {
+	MetricName  string
+	TagKeys     []string
+	DefaultTags map[string]string
+
+	// TimeKey is the key holding the metric time.
+	TimeKey string
+	// TimeFormat is the layout of the timestamp as understood by time.Parse,
+	// or one of "unix", "unix_ms", "unix_us" or "unix_ns".
+	TimeFormat string
+}
+
+// Parse parses every line into a metric. Lines without fields are skipped.
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	now := time.Now().UTC()
+	metrics := make([]telegraf.Metric, 0)
+	for _, line := range strings.Split(string(buf), "\n") {
+		metric, err := p.parseLine(line, now)
+		if err != nil {
+			return nil, err
+		}
+		if metric != nil {
+			metrics = append(metrics, metric)
+		}
+	}
+	return metrics, nil
+}
+
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	metric, err := p.parseLine(line, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	if metric == nil {
+		return nil, fmt.Errorf("Can not parse the line: %s, for data format: logfmt", line)
+	}
+	return metric, nil
+}
+
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
+
+// parseLine returns the metric of a line, or nil if the line has no fields.
+func (p *Parser) parseLine(line string, now time.Time) (telegraf.Metric, error) {
+	pairs, err := split(line)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make(map[string]string)
+	for k, v := range p.DefaultTags {
+		tags[k] = v
+	}
+	fields := make(map[string]interface{})
+	t := now
+
+	for _, pair := range pairs {
+		switch {
+		case pair.key == p.TimeKey:
+			var v interface{} = pair.value
+			if p.TimeFormat == "" && !pair.quoted {
+				v = convert(pair.value)
+			}
+			t, err = internal.ParseTimestamp(v, p.TimeFormat)
+			if err != nil {
+				return nil, fmt.Errorf("logfmt key %s: %s", pair.key, err)
+			}
+		case p.isTag(pair.key):
+			if pair.value != "" {
+				tags[pair.key] = pair.value
+			}
+		case pair.bare:
+			fields[pair.key] = true
+		case pair.quoted:
+			fields[pair.key] = pair.value
+		case pair.value != "":
+			fields[pair.key] = convert(pair.value)
+		}
+	}
+
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	return telegraf.NewMetric(p.MetricName, tags, fields, t)
+}
+
+func (p *Parser) isTag(key string) bool {
+	for _, tag := range p.TagKeys {
+		if tag == key {
+			return true
+		}
+	}
+	return false
+}
+
+type pair struct {
+	key   string
+	value string
+	// bare is set for keys without a value, which are boolean flags.
+	bare bool
+	// quoted is set for values in double quotes, which are always strings.
+	quoted bool
+}
+
+// split splits a line into its key value pairs, ie
+// `level=info msg="request done" status=200 cached`.
+func split(line string) ([]pair, error) {
+	var pairs []pair
+	i := 0
+	for {
+		for i < len(line) && isSpace(line[i]) {
+			i++
+		}
+		if i >= len(line) {
+			return pairs, nil
+		}
+
+		start := i
+		for i < len(line) && line[i] != '=' && !isSpace(line[i]) && line[i] != '"' {
+			i++
+		}
+		if i == start {
+			return nil, fmt.Errorf("logfmt: missing key at column %d", i+1)
+		}
+		pr := pair{key: line[start:i]}
+
+		if i >= len(line) || isSpace(line[i]) {
+			pr.bare = true
+			pairs = append(pairs, pr)
+			continue
+		}
+		if line[i] == '"' {
+			return nil, fmt.Errorf("logfmt: unexpected quote in key at column %d", i+1)
+		}
+
+		// skip the '='
+		i++
+		if i < len(line) && line[i] == '"' {
+			end := i + 1
+			for end < len(line) && line[end] != '"' {
+				if line[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(line) {
+				return nil, fmt.Errorf("logfmt: unterminated quoted value of %s", pr.key)
+			}
+			value, err := strconv.Unquote(line[i : end+1])
+			if err != nil {
+				return nil, fmt.Errorf("logfmt: invalid quoted value of %s: %s", pr.key, err)
+			}
+			pr.value = value
+			pr.quoted = true
+			i = end + 1
+		} else {
+			start = i
+			for i < len(line) && !isSpace(line[i]) {
+				i++
+			}
+			pr.value = line[start:i]
+		}
+		pairs = append(pairs, pr)
+	}
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r'
+}
+
+// convert returns the value as an integer, finite float or boolean if
+// possible, or as a string otherwise. "nan" and "inf" stay strings, as
+// metrics cannot hold them.
+func convert(value string) interface{} {
+	if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(value, 64); err == nil &&
+		!math.IsNaN(f) && !math.IsInf(f, 0) {
+		return f
+	}
+	switch strings.ToLower(value) {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	return value
+}
@@ -0,0 +1,98 @@
+package logfmt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLine(t *testing.T) {
+	parser := Parser{
+		MetricName: "app",
+		TagKeys:    []string{"level", "path"},
+	}
+	metric, err := parser.ParseLine(
+		`level=info path=/api msg="request \"done\"" status=200 duration=0.25 cached=false retry`)
+	require.NoError(t, err)
+	assert.Equal(t, "app", metric.Name())
+	assert.Equal(t, map[string]string{"level": "info", "path": "/api"}, metric.Tags())
+	assert.Equal(t, map[string]interface{}{
+		"msg":      `request "done"`,
+		"status":   int64(200),
+		"duration": float64(0.25),
+		"cached":   false,
+		"retry":    true,
+	}, metric.Fields())
+}
+
+func TestParseQuotedNumber(t *testing.T) {
+	parser := Parser{MetricName: "app"}
+	metric, err := parser.ParseLine(`id="42" empty= count=1`)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"id":    "42",
+		"count": int64(1),
+	}, metric.Fields())
+}
+
+func TestParseNonFiniteFloat(t *testing.T) {
+	parser := Parser{MetricName: "app"}
+	metric, err := parser.ParseLine(`status=nan limit=inf low=-Infinity count=1`)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"status": "nan",
+		"limit":  "inf",
+		"low":    "-Infinity",
+		"count":  int64(1),
+	}, metric.Fields())
+}
+
+func TestParseMultipleLines(t *testing.T) {
+	parser := Parser{
+		MetricName:  "app",
+		DefaultTags: map[string]string{"host": "a"},
+	}
+	metrics, err := parser.Parse([]byte("status=200\n\nstatus=500\n"))
+	require.NoError(t, err)
+	require.Len(t, metrics, 2)
+	assert.Equal(t, map[string]string{"host": "a"}, metrics[1].Tags())
+	assert.Equal(t, map[string]interface{}{"status": int64(500)}, metrics[1].Fields())
+}
+
+func TestParseTime(t *testing.T) {
+	parser := Parser{
+		MetricName: "app",
+		TimeKey:    "ts",
+	}
+	metric, err := parser.ParseLine(`ts=2016-11-22T13:40:00Z status=200`)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2016, 11, 22, 13, 40, 0, 0, time.UTC), metric.Time())
+	assert.Equal(t, map[string]interface{}{"status": int64(200)}, metric.Fields())
+
+	metric, err = parser.ParseLine(`ts=1479822000 status=200`)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1479822000000000000), metric.UnixNano())
+
+	parser.TimeFormat = "unix_ms"
+	metric, err = parser.ParseLine(`ts=1479822000123 status=200`)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1479822000123000000), metric.UnixNano())
+
+	_, err = parser.ParseLine(`ts=now status=200`)
+	assert.Error(t, err)
+}
+
+func TestParseInvalid(t *testing.T) {
+	parser := Parser{MetricName: "app"}
+	for _, line := range []string{
+		`msg="unterminated`,
+		`=value`,
+		`a"b=1`,
+		`level=`,
+	} {
+		_, err := parser.ParseLine(line)
+		assert.Error(t, err, line)
+	}
+}
@@ -0,0 +1,296 @@
+package msgpack
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/influxdata/telegraf"
+)
+
+// maxDepth is the maximum nesting of arrays and maps, protecting the
+// decoder against stack exhaustion.
+const maxDepth = 32
+
+var (
+	errShortBuffer = errors.New("unexpected end of data")
+	errTooDeep     = errors.New("maximum nesting depth exceeded")
+)
+
+// Parser parses the stream of MessagePack maps written by the msgpack
+// serializer. Floats, integers, strings and booleans become fields; other
+// values are skipped.
+type Parser struct {
+	DefaultTags map[string]string
+}
+
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	metrics := make([]telegraf.Metric, 0)
+	d := &decoder{buf: buf}
+	for len(d.buf) > 0 {
+		v, err := d.decode()
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse msgpack data: %s", err)
+		}
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unable to parse msgpack data: %T is not a map", v)
+		}
+		metric, err := p.newMetric(obj)
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, metric)
+	}
+	return metrics, nil
+}
+
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	metrics, err := p.Parse([]byte(line))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(metrics) != 1 {
+		return nil, fmt.Errorf("Can not parse the line: %s, for data format: msgpack", line)
+	}
+
+	return metrics[0], nil
+}
+
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
+
+func (p *Parser) newMetric(obj map[string]interface{}) (telegraf.Metric, error) {
+	name, ok := obj["name"].(string)
+	if !ok || name == "" {
+		return nil, fmt.Errorf("msgpack metric has no name")
+	}
+
+	t := time.Now()
+	if ns, ok := obj["time"].(int64); ok {
+		t = time.Unix(0, ns)
+	}
+
+	tags := make(map[string]string)
+	for k, v := range p.DefaultTags {
+		tags[k] = v
+	}
+	if m, ok := obj["tags"].(map[string]interface{}); ok {
+		for k, v := range m {
+			if s, ok := v.(string); ok {
+				tags[k] = s
+			}
+		}
+	}
+
+	fields := make(map[string]interface{})
+	if m, ok := obj["fields"].(map[string]interface{}); ok {
+		for k, v := range m {
+			switch v.(type) {
+			case int64, float64, string, bool:
+				fields[k] = v
+			}
+		}
+	}
+
+	switch obj["type"] {
+	case "counter":
+		return telegraf.NewCounterMetric(name, tags, fields, t)
+	case "gauge":
+		return telegraf.NewGaugeMetric(name, tags, fields, t)
+	default:
+		return telegraf.NewMetric(name, tags, fields, t)
+	}
+}
+
+// decoder decodes MessagePack values into nil, bool, int64, float64, string,
+// []interface{} and map[string]interface{}. Unsigned integers above the
+// range of int64 become float64, binary data becomes string and extension
+// types are not supported.
+type decoder struct {
+	buf   []byte
+	depth int
+}
+
+func (d *decoder) next(n int) ([]byte, error) {
+	if len(d.buf) < n {
+		return nil, errShortBuffer
+	}
+	b := d.buf[:n]
+	d.buf = d.buf[n:]
+	return b, nil
+}
+
+func (d *decoder) uint(n int) (uint64, error) {
+	b, err := d.next(n)
+	if err != nil {
+		return 0, err
+	}
+	switch n {
+	case 1:
+		return uint64(b[0]), nil
+	case 2:
+		return uint64(binary.BigEndian.Uint16(b)), nil
+	case 4:
+		return uint64(binary.BigEndian.Uint32(b)), nil
+	default:
+		return binary.BigEndian.Uint64(b), nil
+	}
+}
+
+func (d *decoder) decode() (interface{}, error) {
+	b, err := d.next(1)
+	if err != nil {
+		return nil, err
+	}
+	c := b[0]
+
+	switch {
+	case c <= 0x7f:
+		return int64(c), nil
+	case c >= 0xe0:
+		return int64(int8(c)), nil
+	case c&0xf0 == 0x80:
+		return d.decodeMap(int(c & 0x0f))
+	case c&0xf0 == 0x90:
+		return d.decodeArray(int(c & 0x0f))
+	case c&0xe0 == 0xa0:
+		return d.decodeString(int(c & 0x1f))
+	}
+
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xd9:
+		n, err := d.uint(1)
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeString(int(n))
+	case 0xc5, 0xda:
+		n, err := d.uint(2)
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeString(int(n))
+	case 0xc6, 0xdb:
+		n, err := d.uint(4)
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeString(int(n))
+	case 0xca:
+		n, err := d.uint(4)
+		if err != nil {
+			return nil, err
+		}
+		return float64(math.Float32frombits(uint32(n))), nil
+	case 0xcb:
+		n, err := d.uint(8)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(n), nil
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		n, err := d.uint(1 << (c - 0xcc))
+		if err != nil {
+			return nil, err
+		}
+		if n > math.MaxInt64 {
+			return float64(n), nil
+		}
+		return int64(n), nil
+	case 0xd0:
+		n, err := d.uint(1)
+		return int64(int8(n)), err
+	case 0xd1:
+		n, err := d.uint(2)
+		return int64(int16(n)), err
+	case 0xd2:
+		n, err := d.uint(4)
+		return int64(int32(n)), err
+	case 0xd3:
+		n, err := d.uint(8)
+		return int64(n), err
+	case 0xdc, 0xdd:
+		n, err := d.uint(2 << (c - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeArray(int(n))
+	case 0xde, 0xdf:
+		n, err := d.uint(2 << (c - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeMap(int(n))
+	}
+	return nil, fmt.Errorf("unsupported type 0x%02x", c)
+}
+
+func (d *decoder) decodeString(n int) (interface{}, error) {
+	b, err := d.next(n)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (d *decoder) decodeArray(n int) (interface{}, error) {
+	// Every element takes at least a byte.
+	if n > len(d.buf) {
+		return nil, errShortBuffer
+	}
+	if d.depth >= maxDepth {
+		return nil, errTooDeep
+	}
+	d.depth++
+	defer func() { d.depth-- }()
+
+	a := make([]interface{}, 0, n)
+	for i := 0; i < n; i++ {
+		v, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+		a = append(a, v)
+	}
+	return a, nil
+}
+
+func (d *decoder) decodeMap(n int) (interface{}, error) {
+	if n > len(d.buf) {
+		return nil, errShortBuffer
+	}
+	if d.depth >= maxDepth {
+		return nil, errTooDeep
+	}
+	d.depth++
+	defer func() { d.depth-- }()
+
+	m := make(map[string]interface{}, n)
+	for i := 0; i < n; i++ {
+		k, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+		v, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+		key, ok := k.(string)
+		if !ok {
+			return nil, fmt.Errorf("map key %v is not a string", k)
+		}
+		m[key] = v
+	}
+	return m, nil
+}
@@ -0,0 +1,116 @@
+package msgpack
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/serializers/msgpack"
+)
+
+var testTime = time.Unix(1479822000, 123456789)
+
+func TestParseRoundTrip(t *testing.T) {
+	m1, err := telegraf.NewCounterMetric("net",
+		map[string]string{"interface": "eth0"},
+		map[string]interface{}{
+			"bytes_recv": int64(1 << 40),
+			"drop_in":    int64(-1),
+			"ratio":      float64(0.25),
+			"up":         true,
+			"name":       "Intel(R) PRO/1000",
+		},
+		testTime)
+	require.NoError(t, err)
+	m2, err := telegraf.NewGaugeMetric("mem",
+		map[string]string{},
+		map[string]interface{}{"free": int64(200)},
+		testTime)
+	require.NoError(t, err)
+	m3, err := telegraf.NewMetric("cpu",
+		map[string]string{"cpu": "cpu0"},
+		map[string]interface{}{"usage_idle": float64(91)},
+		testTime)
+	require.NoError(t, err)
+
+	s := msgpack.MsgpackSerializer{}
+	buf, err := s.SerializeBatch([]telegraf.Metric{m1, m2, m3})
+	require.NoError(t, err)
+
+	parser := Parser{}
+	metrics, err := parser.Parse(buf)
+	require.NoError(t, err)
+	require.Len(t, metrics, 3)
+	for i, expected := range []telegraf.Metric{m1, m2, m3} {
+		assert.Equal(t, expected.Name(), metrics[i].Name())
+		assert.Equal(t, expected.Tags(), metrics[i].Tags())
+		assert.Equal(t, expected.Fields(), metrics[i].Fields())
+		assert.Equal(t, expected.Type(), metrics[i].Type())
+		assert.Equal(t, expected.UnixNano(), metrics[i].UnixNano())
+	}
+}
+
+func TestParseDefaultTags(t *testing.T) {
+	m, err := telegraf.NewMetric("cpu",
+		map[string]string{"host": "web01"},
+		map[string]interface{}{"usage_idle": float64(91.5)},
+		testTime)
+	require.NoError(t, err)
+
+	s := msgpack.MsgpackSerializer{}
+	lines, err := s.Serialize(m)
+	require.NoError(t, err)
+
+	parser := Parser{}
+	parser.SetDefaultTags(map[string]string{"host": "default", "dc": "eu"})
+	metric, err := parser.ParseLine(lines[0])
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"host": "web01", "dc": "eu"}, metric.Tags())
+}
+
+func TestParseForeignEncoding(t *testing.T) {
+	// {"name": "x", "fields": {"u": uint16(300), "f": float32(0.5)}}
+	buf := []byte{
+		0x82,
+		0xa4, 'n', 'a', 'm', 'e', 0xa1, 'x',
+		0xa6, 'f', 'i', 'e', 'l', 'd', 's', 0x82,
+		0xa1, 'u', 0xcd, 0x01, 0x2c,
+		0xa1, 'f', 0xca, 0x3f, 0x00, 0x00, 0x00,
+	}
+	parser := Parser{}
+	metrics, err := parser.Parse(buf)
+	require.NoError(t, err)
+	require.Len(t, metrics, 1)
+	assert.Equal(t, map[string]interface{}{
+		"u": int64(300),
+		"f": float64(0.5),
+	}, metrics[0].Fields())
+	assert.Equal(t, telegraf.Untyped, metrics[0].Type())
+}
+
+func TestParseInvalid(t *testing.T) {
+	parser := Parser{}
+	_, err := parser.Parse([]byte{0x81, 0xa4, 'n', 'a'})
+	assert.Error(t, err)
+	_, err = parser.Parse([]byte{0x2a})
+	assert.Error(t, err)
+	_, err = parser.Parse([]byte{0xdf, 0xff, 0xff, 0xff, 0xff})
+	assert.Error(t, err)
+}
+
+func TestParseTooDeep(t *testing.T) {
+	// a map holding fixarrays nested a million times
+	buf := []byte{0x81, 0xa1, 'x'}
+	for i := 0; i < 1000000; i++ {
+		buf = append(buf, 0x91)
+	}
+	buf = append(buf, 0xc0)
+
+	parser := Parser{}
+	_, err := parser.Parse(buf)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "nesting depth")
+}
@@ -1,26 +1,62 @@
 package nagios
 
 import (
+	"errors"
+	"fmt"
+	"log"
+	"os/exec"
 	"regexp"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/influxdata/telegraf"
 )
 
+// StateMeasurement is the measurement holding the status text and the exit
+// state of a plugin.
+const StateMeasurement = "nagios_state"
+
 type NagiosParser struct {
 	MetricName  string
 	DefaultTags map[string]string
+
+	// NormalizeUnits converts the values in time and byte units to seconds
+	// and bytes.
+	NormalizeUnits bool
 }
 
-// Got from Alignak
-// https://github.com/Alignak-monitoring/alignak/blob/develop/alignak/misc/perfdata.py
-var perfSplitRegExp, _ = regexp.Compile(`([^=]+=\S+)`)
-var nagiosRegExp, _ = regexp.Compile(`^([^=]+)=([\d\.\-\+eE]+)([\w\/%]*);?([\d\.\-\+eE:~@]+)?;?([\d\.\-\+eE:~@]+)?;?([\d\.\-\+eE]+)?;?([\d\.\-\+eE]+)?;?\s*`)
+// valueRegExp splits a perfdata value from its unit of measurement.
+var valueRegExp = regexp.MustCompile(`^([\d\.\-\+eE]+|U)([\w\/%]*)$`)
+
+// units are the divisors normalizing values to seconds and bytes, when
+// NormalizeUnits is set.
+var units = map[string]struct {
+	unit    string
+	divisor float64
+}{
+	"s":  {"s", 1},
+	"ms": {"s", 1e3},
+	"us": {"s", 1e6},
+	"B":  {"B", 1},
+	"KB": {"B", 1.0 / (1 << 10)},
+	"MB": {"B", 1.0 / (1 << 20)},
+	"GB": {"B", 1.0 / (1 << 30)},
+	"TB": {"B", 1.0 / (1 << 40)},
+}
 
 func (p *NagiosParser) ParseLine(line string) (telegraf.Metric, error) {
 	metrics, err := p.Parse([]byte(line))
-	return metrics[0], err
+	if err != nil {
+		return nil, err
+	}
+
+	if len(metrics) < 1 {
+		return nil, fmt.Errorf("Can not parse the line: %s, for data format: nagios", line)
+	}
+
+	return metrics[0], nil
 }
 
 func (p *NagiosParser) SetDefaultTags(tags map[string]string) {
@@ -29,74 +65,256 @@ func (p *NagiosParser) SetDefaultTags(tags map[string]string) {
 
 //> rta,host=absol,unit=ms critical=6000,min=0,value=0.332,warning=4000 1456374625003628099
 //> pl,host=absol,unit=% critical=90,min=0,value=0,warning=80 1456374625003693967
+//> nagios_state,host=absol service_output="PING OK - Packet loss = 0%",state=0i 1456374625003693967
 
+// Parse parses the output of a nagios plugin into a metric per performance
+// data, followed by a nagios_state metric holding the status text. The
+// performance data are read from the first line and from the lines after the
+// first pipe of the long text, following the plugin development guidelines.
 func (p *NagiosParser) Parse(buf []byte) ([]telegraf.Metric, error) {
 	metrics := make([]telegraf.Metric, 0)
-	// Convert to string
-	out := string(buf)
-	// Prepare output for splitting
-	// Delete escaped pipes
-	out = strings.Replace(out, `\|`, "___PROTECT_PIPE___", -1)
-	// Split lines and get the first one
-	lines := strings.Split(out, "\n")
-	// Split output and perfdatas
-	data_splitted := strings.Split(lines[0], "|")
-	if len(data_splitted) <= 1 {
-		// No pipe == no perf data
-		return nil, nil
-	}
-	// Get perfdatas
-	perfdatas := data_splitted[1]
-	// Add escaped pipes
-	perfdatas = strings.Replace(perfdatas, "___PROTECT_PIPE___", `\|`, -1)
-	// Split perfs
-	unParsedPerfs := perfSplitRegExp.FindAllSubmatch([]byte(perfdatas), -1)
-	// Iterate on all perfs
-	for _, unParsedPerfs := range unParsedPerfs {
-		// Get metrics
-		// Trim perf
-		trimedPerf := strings.Trim(string(unParsedPerfs[0]), " ")
-		// Parse perf
-		perf := nagiosRegExp.FindAllSubmatch([]byte(trimedPerf), -1)
-		// Bad string
-		if len(perf) == 0 {
+	now := time.Now().UTC()
+
+	serviceOutput, longOutput, perfdatas := splitOutput(string(buf))
+	for _, perfdata := range perfdatas {
+		metric, err := p.parsePerfdata(perfdata, now)
+		if err != nil {
+			log.Printf("E! nagios: unable to parse performance data %s: %s\n", perfdata, err)
 			continue
 		}
-		if len(perf[0]) <= 2 {
-			continue
+		if metric != nil {
+			metrics = append(metrics, metric)
 		}
-		if perf[0][1] == nil || perf[0][2] == nil {
-			continue
+	}
+
+	if serviceOutput == "" && longOutput == "" {
+		return metrics, nil
+	}
+	fields := map[string]interface{}{"service_output": serviceOutput}
+	if longOutput != "" {
+		fields["long_service_output"] = longOutput
+	}
+	metric, err := telegraf.NewMetric(StateMeasurement, nil, fields, now)
+	if err != nil {
+		return nil, err
+	}
+	return append(metrics, metric), nil
+}
+
+// splitOutput returns the status text, the long text and the performance
+// data of the output of a plugin.
+func splitOutput(out string) (string, string, []string) {
+	var perfdatas []string
+	lines := strings.Split(strings.TrimRight(out, "\n"), "\n")
+
+	serviceOutput, perfdata, _ := splitPipe(lines[0])
+	perfdatas = append(perfdatas, splitPerfdata(perfdata)...)
+
+	var long []string
+	for i := 1; i < len(lines); i++ {
+		text, perfdata, ok := splitPipe(lines[i])
+		if text != "" {
+			long = append(long, text)
 		}
-		fieldName := string(perf[0][1])
-		tags := make(map[string]string)
-		if perf[0][3] != nil {
-			tags["unit"] = string(perf[0][3])
+		if ok {
+			// The remaining lines are performance data.
+			perfdatas = append(perfdatas, splitPerfdata(perfdata)...)
+			for _, line := range lines[i+1:] {
+				perfdatas = append(perfdatas, splitPerfdata(line)...)
+			}
+			break
 		}
-		fields := make(map[string]interface{})
-		fields["value"] = perf[0][2]
-		// TODO should we set empty field
-		// if metric if there is no data ?
-		if perf[0][4] != nil {
-			fields["warning"] = perf[0][4]
+	}
+
+	return strings.TrimSpace(serviceOutput), strings.Join(long, "\n"), perfdatas
+}
+
+// splitPipe splits a line at its first pipe which is not escaped, and
+// reports whether it was found.
+func splitPipe(line string) (string, string, bool) {
+	for i := 0; i < len(line); i++ {
+		if line[i] == '|' && (i == 0 || line[i-1] != '\\') {
+			return strings.Replace(line[:i], `\|`, "|", -1), line[i+1:], true
 		}
-		if perf[0][5] != nil {
-			fields["critical"] = perf[0][5]
+	}
+	return strings.Replace(line, `\|`, "|", -1), "", false
+}
+
+// splitPerfdata splits space separated performance data. Labels may be
+// quoted with single quotes, two single quotes standing for a quote.
+func splitPerfdata(s string) []string {
+	var perfdatas []string
+	var current []byte
+	quoted := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\'' && quoted && i+1 < len(s) && s[i+1] == '\'':
+			current = append(current, c, c)
+			i++
+		case c == '\'':
+			quoted = !quoted
+			current = append(current, c)
+		case (c == ' ' || c == '\t') && !quoted:
+			if len(current) > 0 {
+				perfdatas = append(perfdatas, string(current))
+				current = current[:0]
+			}
+		default:
+			current = append(current, c)
 		}
-		if perf[0][6] != nil {
-			fields["min"] = perf[0][6]
+	}
+	if len(current) > 0 {
+		perfdatas = append(perfdatas, string(current))
+	}
+	return perfdatas
+}
+
+// parsePerfdata parses 'label'=value[UOM];[warn];[crit];[min];[max] into a
+// metric named after the label. It returns nil if the performance data is
+// malformed or its value unknown, and an error for invalid thresholds.
+func (p *NagiosParser) parsePerfdata(perfdata string, t time.Time) (telegraf.Metric, error) {
+	i := strings.LastIndex(perfdata, "=")
+	if i <= 0 {
+		return nil, nil
+	}
+	label := perfdata[:i]
+	if len(label) >= 2 && label[0] == '\'' && label[len(label)-1] == '\'' {
+		label = strings.Replace(label[1:len(label)-1], "''", "'", -1)
+	}
+	label = strings.TrimSpace(label)
+	if label == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(perfdata[i+1:], ";")
+	m := valueRegExp.FindStringSubmatch(parts[0])
+	if m == nil || m[1] == "U" {
+		return nil, nil
+	}
+	value, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return nil, nil
+	}
+
+	tags := make(map[string]string)
+	unit := m[2]
+	divisor := float64(1)
+	if u, ok := units[unit]; ok && p.NormalizeUnits {
+		unit, divisor = u.unit, u.divisor
+	}
+	if unit != "" && unit != "c" {
+		tags["unit"] = unit
+	}
+
+	fields := map[string]interface{}{"value": value / divisor}
+	for j, name := range []string{"warning", "critical"} {
+		if len(parts) > j+1 && parts[j+1] != "" {
+			if err := addRange(fields, name, parts[j+1], divisor); err != nil {
+				return nil, fmt.Errorf("%s: %s", name, err)
+			}
 		}
-		if perf[0][7] != nil {
-			fields["max"] = perf[0][7]
+	}
+	for j, name := range []string{"min", "max"} {
+		if len(parts) > j+3 && parts[j+3] != "" {
+			v, err := strconv.ParseFloat(parts[j+3], 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s", name, err)
+			}
+			fields[name] = v / divisor
 		}
-		// Create metric
-		metric, err := telegraf.NewMetric(fieldName, tags, fields, time.Now().UTC())
+	}
+
+	if m[2] == "c" {
+		return telegraf.NewCounterMetric(label, tags, fields, t)
+	}
+	return telegraf.NewMetric(label, tags, fields, t)
+}
+
+// addRange adds the fields of a threshold. A single number n, the range
+// 0:n, is added as is. Other ranges are added as their _low and _high
+// bounds, infinite bounds being left out, and ranges starting with "@",
+// alerting inside the range, set the _inside field.
+func addRange(fields map[string]interface{}, name, r string, divisor float64) error {
+	if v, err := strconv.ParseFloat(r, 64); err == nil {
+		fields[name] = v / divisor
+		return nil
+	}
+
+	if strings.HasPrefix(r, "@") {
+		fields[name+"_inside"] = true
+		r = r[1:]
+	}
+
+	bounds := strings.SplitN(r, ":", 2)
+	low, high := bounds[0], ""
+	if len(bounds) == 2 {
+		high = bounds[1]
+	} else {
+		low, high = "0", bounds[0]
+	}
+
+	if low != "~" {
+		if low == "" {
+			low = "0"
+		}
+		v, err := strconv.ParseFloat(low, 64)
 		if err != nil {
-			return nil, err
+			return fmt.Errorf("invalid range %q", r)
 		}
-		// Add Metric
-		metrics = append(metrics, metric)
+		fields[name+"_low"] = v / divisor
+	}
+	if high != "" {
+		v, err := strconv.ParseFloat(high, 64)
+		if err != nil {
+			return fmt.Errorf("invalid range %q", r)
+		}
+		fields[name+"_high"] = v / divisor
+	}
+	return nil
+}
+
+// TryAddState adds the exit state of a plugin, from the error of its command,
+// to the nagios_state metric, which is created if missing.
+func TryAddState(runErr error, metrics []telegraf.Metric) ([]telegraf.Metric, error) {
+	state, err := exitState(runErr)
+	if err != nil {
+		return metrics, err
 	}
 
-	return metrics, nil
+	for i, m := range metrics {
+		if m.Name() != StateMeasurement {
+			continue
+		}
+		fields := m.Fields()
+		fields["state"] = state
+		metric, err := telegraf.NewMetric(m.Name(), m.Tags(), fields, m.Time())
+		if err != nil {
+			return metrics, err
+		}
+		metrics[i] = metric
+		return metrics, nil
+	}
+
+	metric, err := telegraf.NewMetric(StateMeasurement, nil,
+		map[string]interface{}{"state": state}, time.Now().UTC())
+	if err != nil {
+		return metrics, err
+	}
+	return append(metrics, metric), nil
+}
+
+func exitState(runErr error) (int64, error) {
+	if runErr == nil {
+		return 0, nil
+	}
+	exiterr, ok := runErr.(*exec.ExitError)
+	if !ok {
+		return 0, errors.New("unable to get nagios plugin exit code")
+	}
+	status, ok := exiterr.Sys().(syscall.WaitStatus)
+	if !ok || status.ExitStatus() < 0 {
+		return 0, errors.New("unable to get nagios plugin exit code")
+	}
+	return int64(status.ExitStatus()), nil
 }
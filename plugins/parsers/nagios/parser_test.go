@@ -1,10 +1,14 @@
 package nagios
 
 import (
+	"errors"
+	"os/exec"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
 )
 
 const validOutput1 = `PING OK - Packet loss = 0%, RTA = 0.30 ms|rta=0.298000ms;4000.000000;6000.000000;0.000000 pl=0%;80;90;0;100
@@ -24,7 +28,7 @@ func TestParseValidOutput(t *testing.T) {
 	// Output1
 	metrics, err := parser.Parse([]byte(validOutput1))
 	require.NoError(t, err)
-	assert.Len(t, metrics, 2)
+	assert.Len(t, metrics, 3)
 	// rta
 	assert.Equal(t, "rta", metrics[0].Name())
 	assert.Equal(t, map[string]interface{}{
@@ -44,11 +48,17 @@ func TestParseValidOutput(t *testing.T) {
 		"max":      float64(100),
 	}, metrics[1].Fields())
 	assert.Equal(t, map[string]string{"unit": "%"}, metrics[1].Tags())
+	// status text
+	assert.Equal(t, "nagios_state", metrics[2].Name())
+	assert.Equal(t, map[string]interface{}{
+		"service_output":      "PING OK - Packet loss = 0%, RTA = 0.30 ms",
+		"long_service_output": "This is a long output\nwith three lines",
+	}, metrics[2].Fields())
 
 	// Output2
 	metrics, err = parser.Parse([]byte(validOutput2))
 	require.NoError(t, err)
-	assert.Len(t, metrics, 1)
+	assert.Len(t, metrics, 2)
 	// time
 	assert.Equal(t, "time", metrics[0].Name())
 	assert.Equal(t, map[string]interface{}{
@@ -61,7 +71,7 @@ func TestParseValidOutput(t *testing.T) {
 	// Output3
 	metrics, err = parser.Parse([]byte(validOutput3))
 	require.NoError(t, err)
-	assert.Len(t, metrics, 1)
+	assert.Len(t, metrics, 2)
 	// time
 	assert.Equal(t, "time", metrics[0].Name())
 	assert.Equal(t, map[string]interface{}{
@@ -79,11 +89,111 @@ func TestParseInvalidOutput(t *testing.T) {
 	// invalidOutput3
 	metrics, err := parser.Parse([]byte(invalidOutput3))
 	require.NoError(t, err)
-	assert.Len(t, metrics, 0)
+	require.Len(t, metrics, 1)
+	assert.Equal(t, "nagios_state", metrics[0].Name())
 
 	// invalidOutput4
 	metrics, err = parser.Parse([]byte(invalidOutput4))
 	require.NoError(t, err)
-	assert.Len(t, metrics, 0)
+	require.Len(t, metrics, 1)
+	assert.Equal(t, "nagios_state", metrics[0].Name())
+
+}
+
+func TestParseRanges(t *testing.T) {
+	parser := NagiosParser{NormalizeUnits: true}
+
+	metrics, err := parser.Parse([]byte("DISK OK|'/var log'=2048MB;@10:20;~:5;0;4096 load=U;1;2"))
+	require.NoError(t, err)
+	require.Len(t, metrics, 2)
+	assert.Equal(t, "/var log", metrics[0].Name())
+	assert.Equal(t, map[string]interface{}{
+		"value":          float64(2048 << 20),
+		"warning_low":    float64(10 << 20),
+		"warning_high":   float64(20 << 20),
+		"warning_inside": true,
+		"critical_high":  float64(5 << 20),
+		"min":            float64(0),
+		"max":            float64(4096 << 20),
+	}, metrics[0].Fields())
+	assert.Equal(t, map[string]string{"unit": "B"}, metrics[0].Tags())
+	assert.Equal(t, "nagios_state", metrics[1].Name())
+
+	metrics, err = parser.Parse([]byte("OK|time=150us;100:;x:y requests=42c"))
+	require.NoError(t, err)
+	require.Len(t, metrics, 2)
+	assert.Equal(t, "requests", metrics[0].Name())
+	assert.Equal(t, map[string]interface{}{"value": float64(42)}, metrics[0].Fields())
+	assert.Equal(t, telegraf.Counter, metrics[0].Type())
+
+	metrics, err = parser.Parse([]byte("OK|time=150us;100:"))
+	require.NoError(t, err)
+	require.Len(t, metrics, 2)
+	assert.Equal(t, map[string]interface{}{
+		"value":       float64(150) / 1e6,
+		"warning_low": float64(100) / 1e6,
+	}, metrics[0].Fields())
+}
+
+func TestParseUnitsNotNormalized(t *testing.T) {
+	parser := NagiosParser{}
+
+	metrics, err := parser.Parse([]byte("DISK OK|/=2643MB;5948;5958;0;5968 time=150us"))
+	require.NoError(t, err)
+	require.Len(t, metrics, 3)
+	assert.Equal(t, map[string]interface{}{
+		"value":    float64(2643),
+		"warning":  float64(5948),
+		"critical": float64(5958),
+		"min":      float64(0),
+		"max":      float64(5968),
+	}, metrics[0].Fields())
+	assert.Equal(t, map[string]string{"unit": "MB"}, metrics[0].Tags())
+	assert.Equal(t, map[string]interface{}{"value": float64(150)}, metrics[1].Fields())
+	assert.Equal(t, map[string]string{"unit": "us"}, metrics[1].Tags())
+}
+
+func TestParseLongOutputPerfdata(t *testing.T) {
+	parser := NagiosParser{}
+
+	out := "DISK OK - free space: / 3326 MB|/=2643MB;5948;5958;0;5968\n" +
+		"/ 15272 MB (77%);\n" +
+		"/boot 68 MB (69%); | /boot=68MB;88;93;0;98\n" +
+		"/home=69357MB;253404;253409;0;253414\n"
+	metrics, err := parser.Parse([]byte(out))
+	require.NoError(t, err)
+	require.Len(t, metrics, 4)
+	assert.Equal(t, "/", metrics[0].Name())
+	assert.Equal(t, "/boot", metrics[1].Name())
+	assert.Equal(t, "/home", metrics[2].Name())
+	assert.Equal(t, "nagios_state", metrics[3].Name())
+	assert.Equal(t, map[string]interface{}{
+		"service_output":      "DISK OK - free space: / 3326 MB",
+		"long_service_output": "/ 15272 MB (77%);\n/boot 68 MB (69%); ",
+	}, metrics[3].Fields())
+}
+
+func TestTryAddState(t *testing.T) {
+	parser := NagiosParser{}
+	metrics, err := parser.Parse([]byte(validOutput3))
+	require.NoError(t, err)
+
+	metrics, err = TryAddState(nil, metrics)
+	require.NoError(t, err)
+	require.Len(t, metrics, 2)
+	assert.Equal(t, map[string]interface{}{
+		"service_output": "TCP OK - 0.008 second response time on port 80",
+		"state":          int64(0),
+	}, metrics[1].Fields())
+
+	// Without output, the state metric is created.
+	runErr := exec.Command("sh", "-c", "exit 2").Run()
+	metrics, err = TryAddState(runErr, nil)
+	require.NoError(t, err)
+	require.Len(t, metrics, 1)
+	assert.Equal(t, "nagios_state", metrics[0].Name())
+	assert.Equal(t, map[string]interface{}{"state": int64(2)}, metrics[0].Fields())
 
+	_, err = TryAddState(errors.New("timeout"), nil)
+	assert.Error(t, err)
 }
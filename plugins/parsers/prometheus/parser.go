@@ -20,8 +20,53 @@ import (
 	"github.com/prometheus/common/expfmt"
 )
 
-// Parse returns a slice of Metrics from a text representation of a
-// metrics
+// PrometheusParser parses the Prometheus text exposition format.
+type PrometheusParser struct {
+	DefaultTags map[string]string
+}
+
+func (p *PrometheusParser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	metrics, err := Parse(buf, http.Header{})
+	if err != nil || len(p.DefaultTags) == 0 {
+		return metrics, err
+	}
+
+	// labels take precedence over the default tags
+	for i, m := range metrics {
+		tags := m.Tags()
+		for k, v := range p.DefaultTags {
+			if _, ok := tags[k]; !ok {
+				tags[k] = v
+			}
+		}
+		metrics[i], err = telegraf.NewTypedMetric(m.Name(), tags, m.Fields(), m.Time(), m.Type())
+		if err != nil {
+			return nil, err
+		}
+	}
+	return metrics, nil
+}
+
+func (p *PrometheusParser) ParseLine(line string) (telegraf.Metric, error) {
+	metrics, err := p.Parse([]byte(line + "\n"))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(metrics) < 1 {
+		return nil, fmt.Errorf("Can not parse the line: %s, for data format: prometheus", line)
+	}
+
+	return metrics[0], nil
+}
+
+func (p *PrometheusParser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
+
+// Parse returns a slice of Metrics from a text or delimited protocol buffer
+// representation of metrics, depending on the Content-Type of the header.
+// Counters and gauges are returned as metrics of that type.
 func Parse(buf []byte, header http.Header) ([]telegraf.Metric, error) {
 	var metrics []telegraf.Metric
 	var parser expfmt.TextParser
@@ -85,7 +130,7 @@ func Parse(buf []byte, header http.Header) ([]telegraf.Metric, error) {
 				} else {
 					t = time.Now()
 				}
-				metric, err := telegraf.NewMetric(metricName, tags, fields, t)
+				metric, err := telegraf.NewTypedMetric(metricName, tags, fields, t, valueType(mf.GetType()))
 				if err == nil {
 					metrics = append(metrics, metric)
 				}
@@ -96,6 +141,17 @@ func Parse(buf []byte, header http.Header) ([]telegraf.Metric, error) {
 	return metrics, err
 }
 
+func valueType(mt dto.MetricType) telegraf.ValueType {
+	switch mt {
+	case dto.MetricType_COUNTER:
+		return telegraf.Counter
+	case dto.MetricType_GAUGE:
+		return telegraf.Gauge
+	default:
+		return telegraf.Untyped
+	}
+}
+
 // Get Quantiles from summary metric
 func makeQuantiles(m *dto.Metric) map[string]interface{} {
 	fields := make(map[string]interface{})
@@ -133,11 +189,11 @@ func getNameAndValue(m *dto.Metric) map[string]interface{} {
 			fields["gauge"] = float64(m.GetGauge().GetValue())
 		}
 	} else if m.Counter != nil {
-		if !math.IsNaN(m.GetGauge().GetValue()) {
+		if !math.IsNaN(m.GetCounter().GetValue()) {
 			fields["counter"] = float64(m.GetCounter().GetValue())
 		}
 	} else if m.Untyped != nil {
-		if !math.IsNaN(m.GetGauge().GetValue()) {
+		if !math.IsNaN(m.GetUntyped().GetValue()) {
 			fields["value"] = float64(m.GetUntyped().GetValue())
 		}
 	}
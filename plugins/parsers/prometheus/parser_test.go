@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/influxdata/telegraf"
 )
 
 var exptime = time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
@@ -163,3 +165,37 @@ func TestParseValidPrometheus(t *testing.T) {
 		metrics[0].Tags())
 
 }
+
+func TestPrometheusParser(t *testing.T) {
+	parser := PrometheusParser{}
+	parser.SetDefaultTags(map[string]string{"host": "a", "handler": "default"})
+
+	metrics, err := parser.Parse([]byte(validUniqueSummary))
+	assert.NoError(t, err)
+	assert.Len(t, metrics, 1)
+	assert.Equal(t, "http_request_duration_microseconds", metrics[0].Name())
+	assert.Equal(t, map[string]string{"host": "a", "handler": "prometheus"},
+		metrics[0].Tags())
+	assert.Equal(t, float64(9), metrics[0].Fields()["count"])
+	assert.Equal(t, telegraf.Untyped, metrics[0].Type())
+
+	metrics, err = parser.Parse([]byte(validUniqueCounter))
+	assert.NoError(t, err)
+	assert.Len(t, metrics, 1)
+	assert.Equal(t, telegraf.Counter, metrics[0].Type())
+	assert.Equal(t, map[string]string{"host": "a", "handler": "default"},
+		metrics[0].Tags())
+}
+
+func TestPrometheusParseLine(t *testing.T) {
+	parser := PrometheusParser{}
+	metric, err := parser.ParseLine(`http_requests_total{code="200"} 1027 1395066363000`)
+	assert.NoError(t, err)
+	assert.Equal(t, "http_requests_total", metric.Name())
+	assert.Equal(t, map[string]string{"code": "200"}, metric.Tags())
+	assert.Equal(t, map[string]interface{}{"value": float64(1027)}, metric.Fields())
+	assert.Equal(t, int64(1395066363000000000), metric.UnixNano())
+
+	_, err = parser.ParseLine(`# TYPE http_requests_total counter`)
+	assert.Error(t, err)
+}
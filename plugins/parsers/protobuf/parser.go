@@ -0,0 +1,84 @@
+package protobuf
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+
+	"github.com/influxdata/telegraf"
+	pb "github.com/influxdata/telegraf/plugins/serializers/protobuf"
+)
+
+// Parser parses the Batch messages written by the protobuf serializer.
+type Parser struct {
+	DefaultTags map[string]string
+}
+
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	batch := &pb.Batch{}
+	if err := proto.Unmarshal(buf, batch); err != nil {
+		return nil, fmt.Errorf("unable to parse protobuf batch: %s", err)
+	}
+
+	metrics := make([]telegraf.Metric, 0, len(batch.Metrics))
+	for _, m := range batch.Metrics {
+		metric, err := p.newMetric(m)
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, metric)
+	}
+	return metrics, nil
+}
+
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	metrics, err := p.Parse([]byte(line))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(metrics) != 1 {
+		return nil, fmt.Errorf("Can not parse the line: %s, for data format: protobuf", line)
+	}
+
+	return metrics[0], nil
+}
+
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
+
+func (p *Parser) newMetric(m *pb.Metric) (telegraf.Metric, error) {
+	tags := make(map[string]string, len(p.DefaultTags)+len(m.Tags))
+	for k, v := range p.DefaultTags {
+		tags[k] = v
+	}
+	for k, v := range m.Tags {
+		tags[k] = v
+	}
+
+	fields := make(map[string]interface{})
+	for k, v := range m.FloatFields {
+		fields[k] = v
+	}
+	for k, v := range m.IntFields {
+		fields[k] = v
+	}
+	for k, v := range m.StringFields {
+		fields[k] = v
+	}
+	for k, v := range m.BoolFields {
+		fields[k] = v
+	}
+
+	t := time.Unix(0, m.Time)
+	switch m.Type {
+	case pb.ValueType_COUNTER:
+		return telegraf.NewCounterMetric(m.Name, tags, fields, t)
+	case pb.ValueType_GAUGE:
+		return telegraf.NewGaugeMetric(m.Name, tags, fields, t)
+	default:
+		return telegraf.NewMetric(m.Name, tags, fields, t)
+	}
+}
@@ -0,0 +1,83 @@
+package protobuf
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	pb "github.com/influxdata/telegraf/plugins/serializers/protobuf"
+)
+
+var testTime = time.Unix(1479822000, 123456789)
+
+func TestParseRoundTrip(t *testing.T) {
+	m1, err := telegraf.NewCounterMetric("net",
+		map[string]string{"interface": "eth0"},
+		map[string]interface{}{
+			"bytes_recv": int64(1 << 40),
+			"drop_in":    int64(-1),
+			"ratio":      float64(0.25),
+			"up":         true,
+			"down":       false,
+			"name":       "Intel(R) PRO/1000",
+		},
+		testTime)
+	require.NoError(t, err)
+	m2, err := telegraf.NewGaugeMetric("mem",
+		map[string]string{},
+		map[string]interface{}{"free": int64(0)},
+		testTime)
+	require.NoError(t, err)
+	m3, err := telegraf.NewMetric("cpu",
+		map[string]string{"cpu": "cpu0"},
+		map[string]interface{}{"usage_idle": float64(91)},
+		testTime)
+	require.NoError(t, err)
+
+	s := pb.ProtobufSerializer{}
+	buf, err := s.SerializeBatch([]telegraf.Metric{m1, m2})
+	require.NoError(t, err)
+	// Batches can be concatenated.
+	lines, err := s.Serialize(m3)
+	require.NoError(t, err)
+	buf = append(buf, lines[0]...)
+
+	parser := Parser{}
+	metrics, err := parser.Parse(buf)
+	require.NoError(t, err)
+	require.Len(t, metrics, 3)
+	for i, expected := range []telegraf.Metric{m1, m2, m3} {
+		assert.Equal(t, expected.Name(), metrics[i].Name())
+		assert.Equal(t, expected.Tags(), metrics[i].Tags())
+		assert.Equal(t, expected.Fields(), metrics[i].Fields())
+		assert.Equal(t, expected.Type(), metrics[i].Type())
+		assert.Equal(t, expected.UnixNano(), metrics[i].UnixNano())
+	}
+}
+
+func TestParseDefaultTags(t *testing.T) {
+	m, err := telegraf.NewMetric("cpu",
+		map[string]string{"host": "web01"},
+		map[string]interface{}{"usage_idle": float64(91.5)},
+		testTime)
+	require.NoError(t, err)
+
+	s := pb.ProtobufSerializer{}
+	lines, err := s.Serialize(m)
+	require.NoError(t, err)
+
+	parser := Parser{}
+	parser.SetDefaultTags(map[string]string{"host": "default", "dc": "eu"})
+	metric, err := parser.ParseLine(lines[0])
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"host": "web01", "dc": "eu"}, metric.Tags())
+}
+
+func TestParseInvalid(t *testing.T) {
+	parser := Parser{}
+	_, err := parser.Parse([]byte{0x0a, 0x10, 0x01})
+	assert.Error(t, err)
+}
@@ -5,10 +5,17 @@ import (
 
 	"github.com/influxdata/telegraf"
 
+	"github.com/influxdata/telegraf/plugins/parsers/collectd"
+	"github.com/influxdata/telegraf/plugins/parsers/csv"
+	"github.com/influxdata/telegraf/plugins/parsers/dropwizard"
 	"github.com/influxdata/telegraf/plugins/parsers/graphite"
 	"github.com/influxdata/telegraf/plugins/parsers/influx"
 	"github.com/influxdata/telegraf/plugins/parsers/json"
+	"github.com/influxdata/telegraf/plugins/parsers/logfmt"
+	"github.com/influxdata/telegraf/plugins/parsers/msgpack"
 	"github.com/influxdata/telegraf/plugins/parsers/nagios"
+	"github.com/influxdata/telegraf/plugins/parsers/prometheus"
+	"github.com/influxdata/telegraf/plugins/parsers/protobuf"
 	"github.com/influxdata/telegraf/plugins/parsers/value"
 )
 
@@ -40,22 +47,88 @@ type Parser interface {
 // Config is a struct that covers the data types needed for all parser types,
 // and can be used to instantiate _any_ of the parsers.
 type Config struct {
-	// Dataformat can be one of: json, influx, graphite, value, nagios
+	// Dataformat can be one of: json, influx, graphite, value, nagios, csv, logfmt,
+	// collectd, prometheus, dropwizard, msgpack, protobuf
 	DataFormat string
 
-	// Separator only applied to Graphite data.
+	// Separator only applied to Graphite and Dropwizard data.
 	Separator string
-	// Templates only apply to Graphite data.
+	// Templates only apply to Graphite and Dropwizard data.
 	Templates []string
+	// GraphiteTagSupport parses the tags of Graphite tagged series.
+	GraphiteTagSupport bool
 
-	// TagKeys only apply to JSON data
+	// TagKeys only apply to JSON and logfmt data
 	TagKeys []string
+	// JSONQuery selects the part of a JSON document to parse, ie "data.items".
+	JSONQuery string
+	// JSONStringFields are the JSON string fields to keep.
+	JSONStringFields []string
+	// JSONNameKey is the JSON key holding the measurement name.
+	JSONNameKey string
+	// JSONTimeKey is the JSON key holding the timestamp.
+	JSONTimeKey string
+	// JSONTimeFormat is the layout or epoch unit of the JSON timestamp.
+	JSONTimeFormat string
 	// MetricName applies to JSON & value. This will be the name of the measurement.
 	MetricName string
 
 	// DataType only applies to value, this will be the type to parse value to
 	DataType string
 
+	// CSVHeaderRowCount is the number of CSV header rows holding the column names.
+	CSVHeaderRowCount int
+	// CSVSkipRows is the number of lines skipped before the CSV header.
+	CSVSkipRows int
+	// CSVDelimiter separates the CSV columns.
+	CSVDelimiter string
+	// CSVComment is the character starting CSV lines which are ignored.
+	CSVComment string
+	// CSVTrimSpace removes the white space around CSV values.
+	CSVTrimSpace bool
+	// CSVColumnNames are the names of the CSV columns.
+	CSVColumnNames []string
+	// CSVColumnTypes are the types of the CSV columns.
+	CSVColumnTypes []string
+	// CSVTagColumns are the CSV columns added as tags.
+	CSVTagColumns []string
+	// CSVMeasurementColumn is the CSV column holding the measurement name.
+	CSVMeasurementColumn string
+	// CSVTimestampColumn is the CSV column holding the timestamp.
+	CSVTimestampColumn string
+	// CSVTimestampFormat is the layout or epoch unit of the CSV timestamp.
+	CSVTimestampFormat string
+
+	// LogfmtTimeKey is the logfmt key holding the timestamp.
+	LogfmtTimeKey string
+	// LogfmtTimeFormat is the layout or epoch unit of the logfmt timestamp.
+	LogfmtTimeFormat string
+
+	// CollectdAuthFile is the file with the users and passwords of signed
+	// and encrypted collectd packets.
+	CollectdAuthFile string
+	// CollectdSecurityLevel is the minimum security level of collectd
+	// packets, one of "none", "sign" or "encrypt".
+	CollectdSecurityLevel string
+	// CollectdTypesDB are the paths of the collectd types.db files.
+	CollectdTypesDB []string
+	// CollectdSplit is either "split" or "join" for collectd value lists
+	// with multiple values.
+	CollectdSplit string
+
+	// NagiosNormalizeUnits converts the Nagios values in time and byte units
+	// to seconds and bytes.
+	NagiosNormalizeUnits bool
+
+	// DropwizardRegistryPath is the path of the metric registry within the
+	// Dropwizard JSON document.
+	DropwizardRegistryPath string
+	// DropwizardTimePath is the path of the time within the Dropwizard JSON
+	// document.
+	DropwizardTimePath string
+	// DropwizardTimeFormat is the layout or epoch unit of the Dropwizard time.
+	DropwizardTimeFormat string
+
 	// DefaultTags are the default tags that will be added to all parsed metrics.
 	DefaultTags map[string]string
 }
@@ -66,18 +139,32 @@ func NewParser(config *Config) (Parser, error) {
 	var parser Parser
 	switch config.DataFormat {
 	case "json":
-		parser, err = NewJSONParser(config.MetricName,
-			config.TagKeys, config.DefaultTags)
+		parser, err = newJSONParser(config)
 	case "value":
 		parser, err = NewValueParser(config.MetricName,
 			config.DataType, config.DefaultTags)
 	case "influx":
 		parser, err = NewInfluxParser()
 	case "nagios":
-		parser, err = NewNagiosParser()
+		parser, err = newNagiosParser(config)
 	case "graphite":
-		parser, err = NewGraphiteParser(config.Separator,
-			config.Templates, config.DefaultTags)
+		parser, err = newGraphiteParser(config)
+	case "csv":
+		parser, err = newCSVParser(config)
+	case "logfmt":
+		parser, err = newLogfmtParser(config)
+	case "prometheus":
+		parser, err = NewPrometheusParser()
+	case "dropwizard":
+		parser, err = newDropwizardParser(config)
+	case "collectd":
+		parser, err = NewCollectdParser(config.CollectdAuthFile,
+			config.CollectdSecurityLevel, config.CollectdTypesDB,
+			config.CollectdSplit)
+	case "msgpack":
+		parser, err = newMsgpackParser(config)
+	case "protobuf":
+		parser, err = newProtobufParser(config)
 	default:
 		err = fmt.Errorf("Invalid data format: %s", config.DataFormat)
 	}
@@ -97,14 +184,97 @@ func NewJSONParser(
 	return parser, nil
 }
 
+func newJSONParser(config *Config) (Parser, error) {
+	return &json.JSONParser{
+		MetricName:   config.MetricName,
+		TagKeys:      config.TagKeys,
+		DefaultTags:  config.DefaultTags,
+		Query:        config.JSONQuery,
+		StringFields: config.JSONStringFields,
+		NameKey:      config.JSONNameKey,
+		TimeKey:      config.JSONTimeKey,
+		TimeFormat:   config.JSONTimeFormat,
+	}, nil
+}
+
+func newCSVParser(config *Config) (Parser, error) {
+	if config.CSVHeaderRowCount == 0 && len(config.CSVColumnNames) == 0 {
+		return nil, fmt.Errorf("csv_header_row_count or csv_column_names must be set")
+	}
+	return &csv.Parser{
+		MetricName:        config.MetricName,
+		DefaultTags:       config.DefaultTags,
+		HeaderRowCount:    config.CSVHeaderRowCount,
+		SkipRows:          config.CSVSkipRows,
+		Delimiter:         config.CSVDelimiter,
+		Comment:           config.CSVComment,
+		TrimSpace:         config.CSVTrimSpace,
+		ColumnNames:       config.CSVColumnNames,
+		ColumnTypes:       config.CSVColumnTypes,
+		TagColumns:        config.CSVTagColumns,
+		MeasurementColumn: config.CSVMeasurementColumn,
+		TimestampColumn:   config.CSVTimestampColumn,
+		TimestampFormat:   config.CSVTimestampFormat,
+	}, nil
+}
+
+func newLogfmtParser(config *Config) (Parser, error) {
+	return &logfmt.Parser{
+		MetricName:  config.MetricName,
+		TagKeys:     config.TagKeys,
+		DefaultTags: config.DefaultTags,
+		TimeKey:     config.LogfmtTimeKey,
+		TimeFormat:  config.LogfmtTimeFormat,
+	}, nil
+}
+
+func NewCollectdParser(
+	authFile string,
+	securityLevel string,
+	typesDB []string,
+	split string,
+) (Parser, error) {
+	return collectd.NewCollectdParser(authFile, securityLevel, typesDB, split)
+}
+
+func newDropwizardParser(config *Config) (Parser, error) {
+	parser, err := dropwizard.NewParser(config.Separator, config.Templates,
+		config.DefaultTags)
+	if err != nil {
+		return nil, err
+	}
+	parser.RegistryPath = config.DropwizardRegistryPath
+	parser.TimePath = config.DropwizardTimePath
+	parser.TimeFormat = config.DropwizardTimeFormat
+	return parser, nil
+}
+
 func NewNagiosParser() (Parser, error) {
 	return &nagios.NagiosParser{}, nil
 }
 
+func newNagiosParser(config *Config) (Parser, error) {
+	return &nagios.NagiosParser{
+		NormalizeUnits: config.NagiosNormalizeUnits,
+	}, nil
+}
+
+func NewPrometheusParser() (Parser, error) {
+	return &prometheus.PrometheusParser{}, nil
+}
+
 func NewInfluxParser() (Parser, error) {
 	return &influx.InfluxParser{}, nil
 }
 
+func newMsgpackParser(config *Config) (Parser, error) {
+	return &msgpack.Parser{DefaultTags: config.DefaultTags}, nil
+}
+
+func newProtobufParser(config *Config) (Parser, error) {
+	return &protobuf.Parser{DefaultTags: config.DefaultTags}, nil
+}
+
 func NewGraphiteParser(
 	separator string,
 	templates []string,
@@ -113,6 +283,16 @@ func NewGraphiteParser(
 	return graphite.NewGraphiteParser(separator, templates, defaultTags)
 }
 
+func newGraphiteParser(config *Config) (Parser, error) {
+	parser, err := graphite.NewGraphiteParser(config.Separator,
+		config.Templates, config.DefaultTags)
+	if err != nil {
+		return nil, err
+	}
+	parser.TagSupport = config.GraphiteTagSupport
+	return parser, nil
+}
+
 func NewValueParser(
 	metricName string,
 	dataType string,
@@ -1,5 +1,9 @@
 package all
 
 import (
+	_ "github.com/influxdata/telegraf/plugins/processors/cardinality"
+	_ "github.com/influxdata/telegraf/plugins/processors/enrich"
+	_ "github.com/influxdata/telegraf/plugins/processors/expression"
+	_ "github.com/influxdata/telegraf/plugins/processors/lua"
 	_ "github.com/influxdata/telegraf/plugins/processors/printer"
 )
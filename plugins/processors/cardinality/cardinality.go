@@ -0,0 +1,294 @@
+package cardinality
+
+import (
+	"log"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+type Cardinality struct {
+	Limit          int
+	Window         internal.Duration
+	TagLimits      map[string]int
+	TagAction      string
+	Placeholder    string
+	ReportInterval internal.Duration
+
+	// series holds the last time each series was seen, per measurement.
+	series map[string]map[uint64]time.Time
+	// values holds the last time each tag value was seen, per measurement
+	// and tag key.
+	values map[string]map[string]map[string]time.Time
+	// overage counts the dropped and rewritten metrics per measurement since
+	// the last report.
+	overage map[string]*overage
+
+	tagAction string
+	checked   bool
+
+	lastPrune  time.Time
+	lastReport time.Time
+	now        func() time.Time
+}
+
+type overage struct {
+	dropped   int64
+	rewritten int64
+}
+
+var sampleConfig = `
+  ## Maximum number of distinct series (measurement and tag set) per
+  ## measurement seen within the window. New series over the limit are
+  ## dropped. 0 disables the limit.
+  limit = 10000
+
+  ## Series that were not seen for this long are no longer counted. If "0s",
+  ## series are never forgotten.
+  window = "1h"
+
+  ## Maximum number of distinct values per tag key and measurement seen
+  ## within the window.
+  # [processors.cardinality.tag_limits]
+  #   user_id = 100
+
+  ## What to do with metrics having a new tag value over its limit, can be
+  ## "drop" to drop the metric or "rewrite" to replace the value with the
+  ## placeholder. Other values are logged as an error and treated as
+  ## "rewrite".
+  tag_action = "rewrite"
+  placeholder = "_other"
+
+  ## Interval at which the dropped and rewritten metrics are logged and
+  ## reported as a "cardinality" metric. If "0s", the overage is only logged
+  ## once a minute.
+  report_interval = "1m"
+`
+
+func (c *Cardinality) SampleConfig() string {
+	return sampleConfig
+}
+
+func (c *Cardinality) Description() string {
+	return "Limit the number of distinct series per measurement and tag key."
+}
+
+func (c *Cardinality) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	c.check()
+
+	now := c.now()
+	if c.Window.Duration > 0 && now.Sub(c.lastPrune) > c.Window.Duration/10 {
+		c.prune(now)
+		c.lastPrune = now
+	}
+
+	out := in[:0]
+	for _, metric := range in {
+		if m := c.apply(metric, now); m != nil {
+			out = append(out, m)
+		}
+	}
+
+	interval := c.ReportInterval.Duration
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	if c.lastReport.IsZero() {
+		c.lastReport = now
+	} else if now.Sub(c.lastReport) >= interval {
+		out = append(out, c.report(now)...)
+		c.lastReport = now
+	}
+	return out
+}
+
+// apply checks the metric against the limits. It returns nil if the metric
+// should be dropped. Tag values and series are only counted once the metric
+// passes all limits, so that dropped metrics do not use up the limits.
+func (c *Cardinality) apply(m telegraf.Metric, now time.Time) telegraf.Metric {
+	name := m.Name()
+
+	var rewrite map[string]string
+	var seen map[string]string
+	if len(c.TagLimits) > 0 {
+		tags := m.Tags()
+		for key, limit := range c.TagLimits {
+			value, ok := tags[key]
+			if !ok {
+				continue
+			}
+			if c.allowValue(name, key, value, limit) {
+				if seen == nil {
+					seen = make(map[string]string)
+				}
+				seen[key] = value
+				continue
+			}
+			if c.tagAction == "drop" {
+				c.getOverage(name).dropped++
+				return nil
+			}
+			if rewrite == nil {
+				rewrite = tags
+			}
+			rewrite[key] = c.Placeholder
+		}
+	}
+
+	if rewrite != nil {
+		var err error
+		m, err = telegraf.NewTypedMetric(m.Name(), rewrite, m.Fields(), m.Time(), m.Type())
+		if err != nil {
+			log.Printf("E! cardinality: unable to rewrite tags of %s: %s\n",
+				name, err)
+			return nil
+		}
+	}
+
+	if c.Limit > 0 {
+		series, ok := c.series[name]
+		if !ok {
+			series = make(map[uint64]time.Time)
+			c.series[name] = series
+		}
+		id := m.HashID()
+		if _, ok := series[id]; !ok && len(series) >= c.Limit {
+			c.getOverage(name).dropped++
+			return nil
+		}
+		series[id] = now
+	}
+
+	for key, value := range seen {
+		c.values[name][key][value] = now
+	}
+	if rewrite != nil {
+		c.getOverage(name).rewritten++
+	}
+	return m
+}
+
+// allowValue reports whether the tag value is known or there is still room
+// for it.
+func (c *Cardinality) allowValue(name, key, value string, limit int) bool {
+	keys, ok := c.values[name]
+	if !ok {
+		keys = make(map[string]map[string]time.Time)
+		c.values[name] = keys
+	}
+	values, ok := keys[key]
+	if !ok {
+		values = make(map[string]time.Time)
+		keys[key] = values
+	}
+	if _, ok := values[value]; !ok && len(values) >= limit {
+		return false
+	}
+	return true
+}
+
+// check validates the configuration once. An invalid tag action is logged
+// and replaced by "rewrite".
+func (c *Cardinality) check() {
+	if c.checked {
+		return
+	}
+	c.checked = true
+
+	switch c.TagAction {
+	case "drop", "rewrite":
+		c.tagAction = c.TagAction
+	default:
+		log.Printf("E! cardinality: invalid tag_action %q, using rewrite\n",
+			c.TagAction)
+		c.tagAction = "rewrite"
+	}
+}
+
+func (c *Cardinality) getOverage(name string) *overage {
+	o, ok := c.overage[name]
+	if !ok {
+		o = &overage{}
+		c.overage[name] = o
+	}
+	return o
+}
+
+// prune forgets the series and tag values which were not seen within the
+// window. It is not called without a window.
+func (c *Cardinality) prune(now time.Time) {
+	for name, series := range c.series {
+		for id, t := range series {
+			if now.Sub(t) > c.Window.Duration {
+				delete(series, id)
+			}
+		}
+		if len(series) == 0 {
+			delete(c.series, name)
+		}
+	}
+	for name, keys := range c.values {
+		for key, values := range keys {
+			for value, t := range values {
+				if now.Sub(t) > c.Window.Duration {
+					delete(values, value)
+				}
+			}
+			if len(values) == 0 {
+				delete(keys, key)
+			}
+		}
+		if len(keys) == 0 {
+			delete(c.values, name)
+		}
+	}
+}
+
+// report logs the overage since the last report and returns it as metrics.
+func (c *Cardinality) report(now time.Time) []telegraf.Metric {
+	var metrics []telegraf.Metric
+	for name, o := range c.overage {
+		log.Printf("W! cardinality: limit exceeded for %s, dropped %d and "+
+			"rewrote %d metrics\n", name, o.dropped, o.rewritten)
+
+		if c.ReportInterval.Duration <= 0 {
+			continue
+		}
+		m, err := telegraf.NewMetric("cardinality",
+			map[string]string{"measurement": name},
+			map[string]interface{}{
+				"series":    int64(len(c.series[name])),
+				"dropped":   o.dropped,
+				"rewritten": o.rewritten,
+			},
+			now,
+		)
+		if err == nil {
+			metrics = append(metrics, m)
+		}
+	}
+	c.overage = make(map[string]*overage)
+	return metrics
+}
+
+func NewCardinality() *Cardinality {
+	return &Cardinality{
+		Limit:          10000,
+		Window:         internal.Duration{Duration: time.Hour},
+		TagAction:      "rewrite",
+		Placeholder:    "_other",
+		ReportInterval: internal.Duration{Duration: time.Minute},
+		series:         make(map[string]map[uint64]time.Time),
+		values:         make(map[string]map[string]map[string]time.Time),
+		overage:        make(map[string]*overage),
+		now:            time.Now,
+	}
+}
+
+func init() {
+	processors.Add("cardinality", func() telegraf.Processor {
+		return NewCardinality()
+	})
+}
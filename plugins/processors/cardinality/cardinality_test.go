@@ -0,0 +1,148 @@
+package cardinality
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type clock struct {
+	t time.Time
+}
+
+func (c *clock) now() time.Time {
+	return c.t
+}
+
+func newCardinality(c *clock) *Cardinality {
+	card := NewCardinality()
+	card.now = c.now
+	return card
+}
+
+func newStatsd(user string) telegraf.Metric {
+	m, _ := telegraf.NewMetric("requests",
+		map[string]string{"user_id": user},
+		map[string]interface{}{"value": int64(1)},
+		time.Now(),
+	)
+	return m
+}
+
+func TestSeriesLimit(t *testing.T) {
+	c := &clock{t: time.Now()}
+	card := newCardinality(c)
+	card.Limit = 2
+
+	out := card.Apply(newStatsd("a"), newStatsd("b"), newStatsd("c"), newStatsd("a"))
+	require.Len(t, out, 3)
+	assert.Equal(t, "a", out[0].Tags()["user_id"])
+	assert.Equal(t, "b", out[1].Tags()["user_id"])
+	assert.Equal(t, "a", out[2].Tags()["user_id"])
+}
+
+func TestSeriesWindow(t *testing.T) {
+	c := &clock{t: time.Now()}
+	card := newCardinality(c)
+	card.Limit = 1
+	card.ReportInterval.Duration = 2 * card.Window.Duration
+
+	assert.Len(t, card.Apply(newStatsd("a")), 1)
+	assert.Len(t, card.Apply(newStatsd("b")), 0)
+
+	c.t = c.t.Add(card.Window.Duration + time.Second)
+	assert.Len(t, card.Apply(newStatsd("b")), 1)
+	assert.Len(t, card.Apply(newStatsd("a")), 0)
+}
+
+func TestSeriesNoWindow(t *testing.T) {
+	c := &clock{t: time.Now()}
+	card := newCardinality(c)
+	card.Limit = 1
+	card.Window.Duration = 0
+	card.ReportInterval.Duration = 48 * time.Hour
+
+	assert.Len(t, card.Apply(newStatsd("a")), 1)
+	assert.Len(t, card.Apply(newStatsd("b")), 0)
+
+	c.t = c.t.Add(24 * time.Hour)
+	assert.Len(t, card.Apply(newStatsd("b")), 0)
+	assert.Len(t, card.Apply(newStatsd("a")), 1)
+}
+
+func TestTagLimitRewrite(t *testing.T) {
+	c := &clock{t: time.Now()}
+	card := newCardinality(c)
+	card.TagLimits = map[string]int{"user_id": 1}
+
+	out := card.Apply(newStatsd("a"), newStatsd("b"), newStatsd("c"))
+	require.Len(t, out, 3)
+	assert.Equal(t, "a", out[0].Tags()["user_id"])
+	assert.Equal(t, "_other", out[1].Tags()["user_id"])
+	assert.Equal(t, "_other", out[2].Tags()["user_id"])
+}
+
+func TestTagLimitDrop(t *testing.T) {
+	c := &clock{t: time.Now()}
+	card := newCardinality(c)
+	card.TagLimits = map[string]int{"user_id": 2}
+	card.TagAction = "drop"
+
+	out := card.Apply(newStatsd("a"), newStatsd("b"), newStatsd("c"))
+	assert.Len(t, out, 2)
+}
+
+func TestTagLimitInvalidAction(t *testing.T) {
+	c := &clock{t: time.Now()}
+	card := newCardinality(c)
+	card.TagLimits = map[string]int{"user_id": 1}
+	card.TagAction = "ignore"
+
+	out := card.Apply(newStatsd("a"), newStatsd("b"))
+	require.Len(t, out, 2)
+	assert.Equal(t, "_other", out[1].Tags()["user_id"])
+}
+
+func TestSeriesLimitKeepsTagValues(t *testing.T) {
+	c := &clock{t: time.Now()}
+	card := newCardinality(c)
+	card.Limit = 1
+	card.TagLimits = map[string]int{"user_id": 2}
+
+	// b is dropped by the series limit and must not count against the
+	// tag limit
+	out := card.Apply(newStatsd("a"), newStatsd("b"))
+	require.Len(t, out, 1)
+
+	card.Limit = 2
+	out = card.Apply(newStatsd("c"))
+	require.Len(t, out, 1)
+	assert.Equal(t, "c", out[0].Tags()["user_id"])
+}
+
+func TestReport(t *testing.T) {
+	c := &clock{t: time.Now()}
+	card := newCardinality(c)
+	card.Limit = 1
+	card.TagLimits = map[string]int{"user_id": 2}
+
+	card.Apply(newStatsd("a"), newStatsd("b"), newStatsd("c"))
+
+	c.t = c.t.Add(card.ReportInterval.Duration)
+	out := card.Apply()
+	require.Len(t, out, 1)
+	assert.Equal(t, "cardinality", out[0].Name())
+	assert.Equal(t, map[string]string{"measurement": "requests"}, out[0].Tags())
+	assert.Equal(t, map[string]interface{}{
+		"series":    int64(1),
+		"dropped":   int64(2),
+		"rewritten": int64(0),
+	}, out[0].Fields())
+
+	// the counters are reset after each report
+	c.t = c.t.Add(card.ReportInterval.Duration)
+	assert.Len(t, card.Apply(), 0)
+}
@@ -0,0 +1,264 @@
+package enrich
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+type Enrich struct {
+	Files          []string
+	KeyTag         string
+	Overwrite      bool
+	ReloadInterval internal.Duration
+
+	table     *table
+	lastCheck time.Time
+	fileStats map[string]fileStat
+}
+
+// fileStat is used to detect changes to the lookup files between reloads.
+type fileStat struct {
+	modTime time.Time
+	size    int64
+}
+
+// entry is a single glob key of the lookup table and the tags it maps to.
+type entry struct {
+	filter filter.Filter
+	tags   map[string]string
+}
+
+// table holds the parsed lookup files. Keys without glob characters are
+// matched exactly, glob keys are tried in the order they were loaded.
+type table struct {
+	exact map[string]map[string]string
+	globs []entry
+}
+
+var sampleConfig = `
+  ## Files containing the lookup tables. Files ending in ".json" must contain
+  ## an object of key -> {tag: value} objects, all other files are read as
+  ## CSV where the first row holds the tag names and the first column the key.
+  ## Keys may contain glob patterns, ie "web-*".
+  files = ["/etc/telegraf/hosts.csv"]
+
+  ## Tag whose value is looked up in the tables.
+  key_tag = "host"
+
+  ## If true, tags from the lookup table replace tags already on the metric.
+  overwrite = false
+
+  ## How often to check the files for changes.
+  reload_interval = "1m"
+`
+
+func (e *Enrich) SampleConfig() string {
+	return sampleConfig
+}
+
+func (e *Enrich) Description() string {
+	return "Add tags to metrics from lookup tables keyed on a tag value."
+}
+
+func (e *Enrich) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	e.reload()
+	if e.table == nil {
+		return in
+	}
+
+	for i, metric := range in {
+		key, ok := metric.Tags()[e.KeyTag]
+		if !ok {
+			continue
+		}
+		add := e.table.lookup(key)
+		if len(add) == 0 {
+			continue
+		}
+
+		tags := metric.Tags()
+		for k, v := range add {
+			if _, ok := tags[k]; ok && !e.Overwrite {
+				continue
+			}
+			tags[k] = v
+		}
+
+		m, err := telegraf.NewTypedMetric(metric.Name(), tags, metric.Fields(), metric.Time(), metric.Type())
+		if err != nil {
+			log.Printf("E! enrich: unable to add tags to %s: %s\n",
+				metric.Name(), err)
+			continue
+		}
+		in[i] = m
+	}
+	return in
+}
+
+// reload loads the lookup files if they have never been loaded or if any of
+// them changed since the last load. Files are only checked once per
+// ReloadInterval.
+func (e *Enrich) reload() {
+	now := time.Now()
+	if e.table != nil && now.Sub(e.lastCheck) < e.ReloadInterval.Duration {
+		return
+	}
+	e.lastCheck = now
+
+	stats := make(map[string]fileStat)
+	for _, file := range e.Files {
+		info, err := os.Stat(file)
+		if err != nil {
+			log.Printf("E! enrich: unable to stat %s: %s\n", file, err)
+			return
+		}
+		stats[file] = fileStat{modTime: info.ModTime(), size: info.Size()}
+	}
+	if e.table != nil && !changed(e.fileStats, stats) {
+		return
+	}
+
+	t, err := loadTable(e.Files)
+	if err != nil {
+		log.Printf("E! enrich: %s\n", err)
+		return
+	}
+	e.table = t
+	e.fileStats = stats
+}
+
+func changed(old, new map[string]fileStat) bool {
+	if len(old) != len(new) {
+		return true
+	}
+	for file, stat := range new {
+		if old[file] != stat {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *table) lookup(key string) map[string]string {
+	if tags, ok := t.exact[key]; ok {
+		return tags
+	}
+	for _, e := range t.globs {
+		if e.filter.Match(key) {
+			return e.tags
+		}
+	}
+	return nil
+}
+
+func (t *table) add(key string, tags map[string]string) error {
+	if !strings.ContainsAny(key, "*?[") {
+		t.exact[key] = tags
+		return nil
+	}
+	f, err := filter.Compile([]string{key})
+	if err != nil {
+		return fmt.Errorf("invalid key %q: %s", key, err)
+	}
+	t.globs = append(t.globs, entry{filter: f, tags: tags})
+	return nil
+}
+
+func loadTable(files []string) (*table, error) {
+	t := &table{exact: make(map[string]map[string]string)}
+	for _, file := range files {
+		var err error
+		if strings.ToLower(filepath.Ext(file)) == ".json" {
+			err = loadJSON(t, file)
+		} else {
+			err = loadCSV(t, file)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to load %s: %s", file, err)
+		}
+	}
+	return t, nil
+}
+
+func loadJSON(t *table, file string) error {
+	buf, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	var entries map[string]map[string]string
+	if err := json.Unmarshal(buf, &entries); err != nil {
+		return err
+	}
+
+	// sort the keys so that overlapping globs are matched deterministically.
+	keys := make([]string, 0, len(entries))
+	for k := range entries {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if err := t.add(key, entries[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func loadCSV(t *table, file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.Comment = '#'
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	records, err := r.ReadAll()
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		return nil
+	}
+
+	header := records[0]
+	for _, record := range records[1:] {
+		tags := make(map[string]string)
+		for i := 1; i < len(record) && i < len(header); i++ {
+			if record[i] == "" {
+				continue
+			}
+			tags[header[i]] = record[i]
+		}
+		if err := t.add(record[0], tags); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func init() {
+	processors.Add("enrich", func() telegraf.Processor {
+		return &Enrich{
+			KeyTag:         "host",
+			ReloadInterval: internal.Duration{Duration: time.Minute},
+		}
+	})
+}
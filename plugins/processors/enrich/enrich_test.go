@@ -0,0 +1,123 @@
+package enrich
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const hostsCSV = `# key,team,datacenter
+host,team,datacenter
+web01,frontend,ams1
+db-*,storage,fra1
+`
+
+const hostsJSON = `{
+  "web01": {"team": "frontend", "datacenter": "ams1"},
+  "db-*": {"team": "storage"}
+}`
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func newEnrich(files ...string) *Enrich {
+	return &Enrich{
+		Files:  files,
+		KeyTag: "host",
+	}
+}
+
+func newCPUMetric(host string) telegraf.Metric {
+	m, _ := telegraf.NewCounterMetric("cpu",
+		map[string]string{"host": host, "team": "unknown"},
+		map[string]interface{}{"usage_idle": float64(90)},
+		time.Now(),
+	)
+	return m
+}
+
+func TestApplyCSV(t *testing.T) {
+	dir, err := ioutil.TempDir("", "enrich")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	e := newEnrich(writeFile(t, dir, "hosts.csv", hostsCSV))
+	out := e.Apply(newCPUMetric("web01"), newCPUMetric("db-3"), newCPUMetric("other"))
+	require.Len(t, out, 3)
+
+	assert.Equal(t, map[string]string{
+		"host":       "web01",
+		"team":       "unknown",
+		"datacenter": "ams1",
+	}, out[0].Tags())
+	assert.Equal(t, telegraf.Counter, out[0].Type())
+	assert.Equal(t, map[string]string{
+		"host":       "db-3",
+		"team":       "unknown",
+		"datacenter": "fra1",
+	}, out[1].Tags())
+	assert.Equal(t, map[string]string{
+		"host": "other",
+		"team": "unknown",
+	}, out[2].Tags())
+}
+
+func TestApplyJSONOverwrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "enrich")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	e := newEnrich(writeFile(t, dir, "hosts.json", hostsJSON))
+	e.Overwrite = true
+	out := e.Apply(newCPUMetric("web01"), newCPUMetric("db-1"))
+	require.Len(t, out, 2)
+
+	assert.Equal(t, map[string]string{
+		"host":       "web01",
+		"team":       "frontend",
+		"datacenter": "ams1",
+	}, out[0].Tags())
+	assert.Equal(t, map[string]string{
+		"host": "db-1",
+		"team": "storage",
+	}, out[1].Tags())
+}
+
+func TestReloadOnChange(t *testing.T) {
+	dir, err := ioutil.TempDir("", "enrich")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := writeFile(t, dir, "hosts.csv", "host,rack\nweb01,r1\n")
+	e := newEnrich(path)
+
+	out := e.Apply(newCPUMetric("web01"))
+	assert.Equal(t, "r1", out[0].Tags()["rack"])
+
+	writeFile(t, dir, "hosts.csv", "host,rack\nweb01,r22\n")
+	out = e.Apply(newCPUMetric("web01"))
+	assert.Equal(t, "r22", out[0].Tags()["rack"])
+}
+
+func TestKeepTableOnError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "enrich")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := writeFile(t, dir, "hosts.json", `{"web01": {"rack": "r1"}}`)
+	e := newEnrich(path)
+	e.Apply(newCPUMetric("web01"))
+
+	writeFile(t, dir, "hosts.json", `{"web01": `)
+	out := e.Apply(newCPUMetric("web01"))
+	assert.Equal(t, "r1", out[0].Tags()["rack"])
+}
@@ -0,0 +1,583 @@
+package expression
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// env resolves the identifiers of an expression. Fields take precedence over
+// tags of the same name.
+type env struct {
+	fields map[string]interface{}
+	tags   map[string]string
+}
+
+func (e *env) lookup(name string) (interface{}, error) {
+	if v, ok := e.fields[name]; ok {
+		return v, nil
+	}
+	if v, ok := e.tags[name]; ok {
+		return v, nil
+	}
+	return nil, fmt.Errorf("unknown field or tag %q", name)
+}
+
+// node is a node of a parsed expression. Evaluation has no side effects and
+// every node is evaluated at most once, so the cost of an expression is bound
+// by its length.
+type node interface {
+	eval(e *env) (interface{}, error)
+}
+
+type literal struct {
+	value interface{}
+}
+
+func (n *literal) eval(e *env) (interface{}, error) {
+	return n.value, nil
+}
+
+type ident struct {
+	name string
+}
+
+func (n *ident) eval(e *env) (interface{}, error) {
+	return e.lookup(n.name)
+}
+
+type unary struct {
+	op string
+	x  node
+}
+
+func (n *unary) eval(e *env) (interface{}, error) {
+	x, err := n.x.eval(e)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "!":
+		b, ok := x.(bool)
+		if !ok {
+			return nil, fmt.Errorf("operator ! not defined on %T", x)
+		}
+		return !b, nil
+	case "-":
+		switch v := x.(type) {
+		case int64:
+			return -v, nil
+		case float64:
+			return -v, nil
+		}
+		return nil, fmt.Errorf("operator - not defined on %T", x)
+	}
+	return nil, fmt.Errorf("unknown operator %s", n.op)
+}
+
+type binary struct {
+	op   string
+	x, y node
+}
+
+func (n *binary) eval(e *env) (interface{}, error) {
+	x, err := n.x.eval(e)
+	if err != nil {
+		return nil, err
+	}
+
+	// short-circuit the logical operators
+	if n.op == "&&" || n.op == "||" {
+		b, ok := x.(bool)
+		if !ok {
+			return nil, fmt.Errorf("operator %s not defined on %T", n.op, x)
+		}
+		if (n.op == "&&" && !b) || (n.op == "||" && b) {
+			return b, nil
+		}
+		y, err := n.y.eval(e)
+		if err != nil {
+			return nil, err
+		}
+		b, ok = y.(bool)
+		if !ok {
+			return nil, fmt.Errorf("operator %s not defined on %T", n.op, y)
+		}
+		return b, nil
+	}
+
+	y, err := n.y.eval(e)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==", "!=":
+		eq, err := equal(x, y)
+		if err != nil {
+			return nil, err
+		}
+		return eq == (n.op == "=="), nil
+	}
+
+	if xs, ok := x.(string); ok {
+		ys, ok := y.(string)
+		if !ok {
+			return nil, fmt.Errorf("mismatched types string and %T", y)
+		}
+		switch n.op {
+		case "+":
+			return xs + ys, nil
+		case "<":
+			return xs < ys, nil
+		case "<=":
+			return xs <= ys, nil
+		case ">":
+			return xs > ys, nil
+		case ">=":
+			return xs >= ys, nil
+		}
+		return nil, fmt.Errorf("operator %s not defined on string", n.op)
+	}
+
+	xi, xIsInt := x.(int64)
+	yi, yIsInt := y.(int64)
+	if xIsInt && yIsInt {
+		switch n.op {
+		case "+":
+			return xi + yi, nil
+		case "-":
+			return xi - yi, nil
+		case "*":
+			return xi * yi, nil
+		case "%":
+			if yi == 0 {
+				return nil, fmt.Errorf("integer division by zero")
+			}
+			return xi % yi, nil
+		}
+	}
+
+	xf, ok := toFloat(x)
+	if !ok {
+		return nil, fmt.Errorf("operator %s not defined on %T", n.op, x)
+	}
+	yf, ok := toFloat(y)
+	if !ok {
+		return nil, fmt.Errorf("operator %s not defined on %T", n.op, y)
+	}
+	switch n.op {
+	case "+":
+		return xf + yf, nil
+	case "-":
+		return xf - yf, nil
+	case "*":
+		return xf * yf, nil
+	case "/":
+		return xf / yf, nil
+	case "%":
+		return math.Mod(xf, yf), nil
+	case "<":
+		return xf < yf, nil
+	case "<=":
+		return xf <= yf, nil
+	case ">":
+		return xf > yf, nil
+	case ">=":
+		return xf >= yf, nil
+	}
+	return nil, fmt.Errorf("unknown operator %s", n.op)
+}
+
+type call struct {
+	name string
+	args []node
+}
+
+func (n *call) eval(e *env) (interface{}, error) {
+	args := make([]interface{}, len(n.args))
+	for i, arg := range n.args {
+		v, err := arg.eval(e)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+
+	switch n.name {
+	case "field", "tag":
+		name, ok := args[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("%s() expects a string argument", n.name)
+		}
+		if n.name == "tag" {
+			if v, ok := e.tags[name]; ok {
+				return v, nil
+			}
+			return nil, fmt.Errorf("unknown tag %q", name)
+		}
+		if v, ok := e.fields[name]; ok {
+			return v, nil
+		}
+		return nil, fmt.Errorf("unknown field %q", name)
+	case "int":
+		switch v := args[0].(type) {
+		case int64:
+			return v, nil
+		case float64:
+			return int64(v), nil
+		case bool:
+			if v {
+				return int64(1), nil
+			}
+			return int64(0), nil
+		case string:
+			return strconv.ParseInt(v, 10, 64)
+		}
+	case "float":
+		switch v := args[0].(type) {
+		case string:
+			return strconv.ParseFloat(v, 64)
+		case bool:
+			if v {
+				return float64(1), nil
+			}
+			return float64(0), nil
+		}
+		if f, ok := toFloat(args[0]); ok {
+			return f, nil
+		}
+	case "abs":
+		switch v := args[0].(type) {
+		case int64:
+			if v < 0 {
+				return -v, nil
+			}
+			return v, nil
+		case float64:
+			return math.Abs(v), nil
+		}
+	case "round", "floor", "ceil":
+		f, ok := toFloat(args[0])
+		if !ok {
+			break
+		}
+		switch n.name {
+		case "round":
+			return math.Floor(f + 0.5), nil
+		case "floor":
+			return math.Floor(f), nil
+		default:
+			return math.Ceil(f), nil
+		}
+	case "min", "max":
+		x, xok := toFloat(args[0])
+		y, yok := toFloat(args[1])
+		if !xok || !yok {
+			break
+		}
+		if (n.name == "min") == (x < y) {
+			return args[0], nil
+		}
+		return args[1], nil
+	}
+	return nil, fmt.Errorf("%s() not defined on %T", n.name, args[0])
+}
+
+// functions maps the names of the builtin functions to their number of
+// arguments.
+var functions = map[string]int{
+	"field": 1,
+	"tag":   1,
+	"int":   1,
+	"float": 1,
+	"abs":   1,
+	"round": 1,
+	"floor": 1,
+	"ceil":  1,
+	"min":   2,
+	"max":   2,
+}
+
+func equal(x, y interface{}) (bool, error) {
+	switch xv := x.(type) {
+	case string:
+		yv, ok := y.(string)
+		if !ok {
+			return false, fmt.Errorf("mismatched types string and %T", y)
+		}
+		return xv == yv, nil
+	case bool:
+		yv, ok := y.(bool)
+		if !ok {
+			return false, fmt.Errorf("mismatched types bool and %T", y)
+		}
+		return xv == yv, nil
+	}
+	xf, xok := toFloat(x)
+	yf, yok := toFloat(y)
+	if !xok || !yok {
+		return false, fmt.Errorf("mismatched types %T and %T", x, y)
+	}
+	return xf == yf, nil
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+// binary operators by precedence, lowest first.
+var precedence = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!=", "<=", ">=", "<", ">"},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+type token struct {
+	kind  tokenKind
+	text  string
+	value interface{}
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokString
+	tokIdent
+	tokOp
+)
+
+var operators = []string{
+	"&&", "||", "==", "!=", "<=", ">=",
+	"<", ">", "+", "-", "*", "/", "%", "!", "(", ")", ",",
+}
+
+func tokenize(s string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c >= '0' && c <= '9' || c == '.':
+			j := i
+			for j < len(s) && (isDigit(s[j]) || s[j] == '.' ||
+				s[j] == 'e' || s[j] == 'E' ||
+				((s[j] == '+' || s[j] == '-') && (s[j-1] == 'e' || s[j-1] == 'E'))) {
+				j++
+			}
+			text := s[i:j]
+			if iv, err := strconv.ParseInt(text, 10, 64); err == nil {
+				tokens = append(tokens, token{kind: tokNumber, text: text, value: iv})
+			} else if fv, err := strconv.ParseFloat(text, 64); err == nil {
+				tokens = append(tokens, token{kind: tokNumber, text: text, value: fv})
+			} else {
+				return nil, fmt.Errorf("invalid number %q", text)
+			}
+			i = j
+		case c == '"' || c == '\'':
+			var value []byte
+			j := i + 1
+			for ; j < len(s) && s[j] != c; j++ {
+				if s[j] == '\\' && j+1 < len(s) {
+					j++
+				}
+				value = append(value, s[j])
+			}
+			if j >= len(s) {
+				return nil, fmt.Errorf("unterminated string at offset %d", i)
+			}
+			tokens = append(tokens, token{kind: tokString, text: s[i : j+1], value: string(value)})
+			i = j + 1
+		case isLetter(c):
+			j := i
+			for j < len(s) && (isLetter(s[j]) || isDigit(s[j])) {
+				j++
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: s[i:j]})
+			i = j
+		default:
+			found := false
+			for _, op := range operators {
+				if strings.HasPrefix(s[i:], op) {
+					tokens = append(tokens, token{kind: tokOp, text: op})
+					i += len(op)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("unexpected character %q at offset %d", c, i)
+			}
+		}
+	}
+	return append(tokens, token{kind: tokEOF}), nil
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isLetter(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// parse parses an expression such as "used / total * 100" into a tree of
+// nodes that can be evaluated against the fields and tags of a metric.
+func parse(s string) (node, error) {
+	tokens, err := tokenize(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	n, err := p.parseBinary(0)
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %q", tok.text)
+	}
+	return n, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) expect(op string) error {
+	if tok := p.next(); tok.kind != tokOp || tok.text != op {
+		if tok.kind == tokEOF {
+			return fmt.Errorf("expected %q, got end of expression", op)
+		}
+		return fmt.Errorf("expected %q, got %q", op, tok.text)
+	}
+	return nil
+}
+
+func (p *parser) parseBinary(level int) (node, error) {
+	if level == len(precedence) {
+		return p.parseUnary()
+	}
+
+	x, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := p.peek()
+		if tok.kind != tokOp || !contains(precedence[level], tok.text) {
+			return x, nil
+		}
+		p.next()
+		y, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		x = &binary{op: tok.text, x: x, y: y}
+	}
+}
+
+func (p *parser) parseUnary() (node, error) {
+	tok := p.peek()
+	if tok.kind == tokOp && (tok.text == "-" || tok.text == "!") {
+		p.next()
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unary{op: tok.text, x: x}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokNumber, tokString:
+		return &literal{value: tok.value}, nil
+	case tokIdent:
+		switch tok.text {
+		case "true":
+			return &literal{value: true}, nil
+		case "false":
+			return &literal{value: false}, nil
+		}
+		if p.peek().kind == tokOp && p.peek().text == "(" {
+			return p.parseCall(tok.text)
+		}
+		return &ident{name: tok.text}, nil
+	case tokOp:
+		if tok.text == "(" {
+			x, err := p.parseBinary(0)
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return x, nil
+		}
+		return nil, fmt.Errorf("unexpected %q", tok.text)
+	}
+	return nil, fmt.Errorf("unexpected end of expression")
+}
+
+func (p *parser) parseCall(name string) (node, error) {
+	nargs, ok := functions[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %s()", name)
+	}
+	p.next()
+
+	c := &call{name: name}
+	for i := 0; i < nargs; i++ {
+		if i > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		arg, err := p.parseBinary(0)
+		if err != nil {
+			return nil, err
+		}
+		c.args = append(c.args, arg)
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+package expression
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEval(t *testing.T) {
+	e := &env{
+		fields: map[string]interface{}{
+			"used":  int64(25),
+			"total": int64(100),
+			"temp":  float64(-3.5),
+			"up":    true,
+			"state": "running",
+		},
+		tags: map[string]string{"host": "web01", "used": "ignored"},
+	}
+
+	tests := []struct {
+		expr     string
+		expected interface{}
+	}{
+		{"used / total * 100", float64(25)},
+		{"used + total", int64(125)},
+		{"total % 7", int64(2)},
+		{"-temp", float64(3.5)},
+		{"abs(temp) * 2", float64(7)},
+		{"round(temp)", float64(-3)},
+		{"max(used, total)", int64(100)},
+		{"min(used, temp)", float64(-3.5)},
+		{"float(used) / 2", float64(12.5)},
+		{"int(temp)", int64(-3)},
+		{"1.5e2 + 1", float64(151)},
+		{"(used + 5) * 2", int64(60)},
+		{"used > 10 && up", true},
+		{"!up || used == 25", true},
+		{"state == 'running'", true},
+		{"host != \"web02\"", true},
+		{"tag(\"used\") + \"!\"", "ignored!"},
+		{"field('used') * 1.0", float64(25)},
+		{"used < 10 && missing > 0", false},
+	}
+
+	for _, test := range tests {
+		n, err := parse(test.expr)
+		require.NoError(t, err, test.expr)
+		v, err := n.eval(e)
+		require.NoError(t, err, test.expr)
+		assert.Equal(t, test.expected, v, test.expr)
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	e := &env{
+		fields: map[string]interface{}{"used": int64(25), "state": "running"},
+	}
+
+	for _, expr := range []string{
+		"missing + 1",
+		"state * 2",
+		"used % 0",
+		"!used",
+		"used && true",
+	} {
+		n, err := parse(expr)
+		require.NoError(t, err, expr)
+		_, err = n.eval(e)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"used +",
+		"(used",
+		"used)",
+		"exec('rm')",
+		"min(used)",
+		"'unterminated",
+		"used $ 2",
+	} {
+		_, err := parse(expr)
+		assert.Error(t, err, expr)
+	}
+}
@@ -0,0 +1,152 @@
+package expression
+
+import (
+	"fmt"
+	"log"
+	"math"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/models"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+type Expression struct {
+	Fields []*Field
+
+	compiled bool
+}
+
+// Field is a single expression and the name of the field its result is
+// written to. The expression is only evaluated for metrics passing its
+// filter.
+type Field struct {
+	Field      string
+	Expression string
+
+	NamePass []string            `toml:"namepass"`
+	NameDrop []string            `toml:"namedrop"`
+	TagPass  map[string][]string `toml:"tagpass"`
+	TagDrop  map[string][]string `toml:"tagdrop"`
+
+	filter models.Filter
+	expr   node
+}
+
+var sampleConfig = `
+  ## Each expression is evaluated over the fields and tags of a metric and
+  ## the result is written to a new field. Identifiers refer to fields first,
+  ## then tags. Supported are the operators + - * / % == != < <= > >= && || !
+  ## and the functions abs, round, floor, ceil, min, max, int, float,
+  ## field("name") and tag("name").
+  ## If a field or tag used in the expression is missing, the field is not
+  ## added to the metric.
+  [[processors.expression.fields]]
+    field = "used_percent"
+    expression = "used / total * 100"
+    ## Expressions can be restricted to certain metrics with the namepass,
+    ## namedrop, tagpass and tagdrop filters.
+    namepass = ["mem"]
+
+  [[processors.expression.fields]]
+    field = "bits_recv"
+    expression = "bytes_recv * 8"
+    namepass = ["net"]
+    [processors.expression.fields.tagdrop]
+      interface = ["lo"]
+`
+
+func (e *Expression) SampleConfig() string {
+	return sampleConfig
+}
+
+func (e *Expression) Description() string {
+	return "Add fields computed from expressions over the fields and tags of a metric."
+}
+
+func (e *Expression) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	if !e.compiled {
+		e.compile()
+	}
+
+	for i, metric := range in {
+		fields := metric.Fields()
+		tags := metric.Tags()
+		env := &env{fields: fields, tags: tags}
+
+		var changed bool
+		for _, f := range e.Fields {
+			if f.expr == nil || !f.filter.Apply(metric.Name(), metric.Fields(), metric.Tags()) {
+				continue
+			}
+
+			v, err := f.expr.eval(env)
+			if err != nil {
+				continue
+			}
+			if fv, ok := v.(float64); ok && (math.IsNaN(fv) || math.IsInf(fv, 0)) {
+				continue
+			}
+			fields[f.Field] = v
+			changed = true
+		}
+		if !changed {
+			continue
+		}
+
+		m, err := telegraf.NewTypedMetric(metric.Name(), tags, fields, metric.Time(), metric.Type())
+		if err != nil {
+			log.Printf("E! expression: unable to add fields to %s: %s\n",
+				metric.Name(), err)
+			continue
+		}
+		in[i] = m
+	}
+	return in
+}
+
+// compile parses the expressions and builds their filters. Invalid
+// expressions are logged and disabled.
+func (e *Expression) compile() {
+	e.compiled = true
+	for _, f := range e.Fields {
+		if err := f.compile(); err != nil {
+			log.Printf("E! expression: field %q: %s\n", f.Field, err)
+			f.expr = nil
+		}
+	}
+}
+
+func (f *Field) compile() error {
+	if f.Field == "" {
+		return fmt.Errorf("field name must not be empty")
+	}
+
+	f.filter = models.Filter{
+		NamePass: f.NamePass,
+		NameDrop: f.NameDrop,
+	}
+	for name, values := range f.TagPass {
+		f.filter.TagPass = append(f.filter.TagPass,
+			models.TagFilter{Name: name, Filter: values})
+	}
+	for name, values := range f.TagDrop {
+		f.filter.TagDrop = append(f.filter.TagDrop,
+			models.TagFilter{Name: name, Filter: values})
+	}
+	if err := f.filter.Compile(); err != nil {
+		return err
+	}
+
+	expr, err := parse(f.Expression)
+	if err != nil {
+		return fmt.Errorf("invalid expression %q: %s", f.Expression, err)
+	}
+	f.expr = expr
+	return nil
+}
+
+func init() {
+	processors.Add("expression", func() telegraf.Processor {
+		return &Expression{}
+	})
+}
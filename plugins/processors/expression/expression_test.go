@@ -0,0 +1,84 @@
+package expression
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/stretchr/testify/assert"
+)
+
+func newMem() telegraf.Metric {
+	m, _ := telegraf.NewGaugeMetric("mem",
+		map[string]string{"host": "web01"},
+		map[string]interface{}{"used": int64(25), "total": int64(100)},
+		time.Now(),
+	)
+	return m
+}
+
+func newNet(iface string) telegraf.Metric {
+	m, _ := telegraf.NewCounterMetric("net",
+		map[string]string{"interface": iface},
+		map[string]interface{}{"bytes_recv": int64(10)},
+		time.Now(),
+	)
+	return m
+}
+
+func TestApply(t *testing.T) {
+	e := &Expression{
+		Fields: []*Field{
+			{
+				Field:      "used_percent",
+				Expression: "used / total * 100",
+				NamePass:   []string{"mem"},
+			},
+			{
+				Field:      "high",
+				Expression: "used_percent > 20",
+			},
+			{
+				Field:      "bits_recv",
+				Expression: "bytes_recv * 8",
+				TagDrop:    map[string][]string{"interface": []string{"lo"}},
+			},
+		},
+	}
+
+	out := e.Apply(newMem(), newNet("eth0"), newNet("lo"))
+	assert.Len(t, out, 3)
+
+	assert.Equal(t, map[string]interface{}{
+		"used":         int64(25),
+		"total":        int64(100),
+		"used_percent": float64(25),
+		"high":         true,
+	}, out[0].Fields())
+	assert.Equal(t, telegraf.Gauge, out[0].Type())
+
+	assert.Equal(t, map[string]interface{}{
+		"bytes_recv": int64(10),
+		"bits_recv":  int64(80),
+	}, out[1].Fields())
+	assert.Equal(t, telegraf.Counter, out[1].Type())
+
+	assert.Equal(t, map[string]interface{}{
+		"bytes_recv": int64(10),
+	}, out[2].Fields())
+}
+
+func TestApplySkipsInvalid(t *testing.T) {
+	e := &Expression{
+		Fields: []*Field{
+			{Field: "bad", Expression: "used +"},
+			{Field: "inf", Expression: "used / 0"},
+		},
+	}
+
+	out := e.Apply(newMem())
+	assert.Equal(t, map[string]interface{}{
+		"used":  int64(25),
+		"total": int64(100),
+	}, out[0].Fields())
+}
@@ -0,0 +1,312 @@
+package lua
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math"
+	"time"
+
+	"github.com/yuin/gopher-lua"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+type Lua struct {
+	Script  string
+	Source  string
+	Timeout internal.Duration
+
+	state  *lua.LState
+	loaded bool
+}
+
+var sampleConfig = `
+  ## Path of the Lua script, or the script itself in "source". The script
+  ## must define a function "apply(metric)" which is called for every metric.
+  ## A metric is a table with the keys "name", "tags", "fields", "time"
+  ## (nanoseconds) and "type" ("counter", "gauge" or "untyped").
+  ## The function returns the (modified) metric, nil to drop it, or a list of
+  ## metrics to split it or emit new metrics.
+  ## Global variables, such as the predefined "state" table, persist between
+  ## calls. Only the base, string, table and math libraries are available.
+  script = "/etc/telegraf/transform.lua"
+  # source = '''
+  # function apply(metric)
+  #   metric.fields.used_percent = metric.fields.used / metric.fields.total * 100
+  #   return metric
+  # end
+  # '''
+
+  ## Maximum time the script may run when loaded and for every metric. A
+  ## metric the script times out on is passed unmodified. 0 disables it.
+  # timeout = "1s"
+`
+
+func (l *Lua) SampleConfig() string {
+	return sampleConfig
+}
+
+func (l *Lua) Description() string {
+	return "Transform metrics with a Lua script."
+}
+
+func (l *Lua) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	if !l.loaded {
+		l.loaded = true
+		if err := l.load(); err != nil {
+			log.Printf("E! lua: %s\n", err)
+			l.state = nil
+		}
+	}
+	if l.state == nil {
+		return in
+	}
+
+	out := make([]telegraf.Metric, 0, len(in))
+	for _, metric := range in {
+		metrics, err := l.apply(metric)
+		if err != nil {
+			log.Printf("E! lua: %s, passing metric %s unmodified\n",
+				err, metric.Name())
+			out = append(out, metric)
+			continue
+		}
+		out = append(out, metrics...)
+	}
+	return out
+}
+
+// load creates the interpreter with the sandboxed libraries and runs the
+// script.
+func (l *Lua) load() error {
+	source := l.Source
+	if l.Script != "" {
+		buf, err := ioutil.ReadFile(l.Script)
+		if err != nil {
+			return err
+		}
+		source = string(buf)
+	}
+
+	L := lua.NewState(lua.Options{SkipOpenLibs: true})
+	for _, lib := range []struct {
+		name string
+		open lua.LGFunction
+	}{
+		{lua.BaseLibName, lua.OpenBase},
+		{lua.TabLibName, lua.OpenTable},
+		{lua.StringLibName, lua.OpenString},
+		{lua.MathLibName, lua.OpenMath},
+	} {
+		L.Push(L.NewFunction(lib.open))
+		L.Push(lua.LString(lib.name))
+		L.Call(1, 0)
+	}
+	// remove the functions giving access to the file system
+	for _, name := range []string{
+		"dofile", "loadfile", "load", "loadstring", "require", "module",
+	} {
+		L.SetGlobal(name, lua.LNil)
+	}
+	L.SetGlobal("state", L.NewTable())
+
+	err := l.withTimeout(L, func() error {
+		return L.DoString(source)
+	})
+	if err != nil {
+		L.Close()
+		return err
+	}
+	if L.GetGlobal("apply").Type() != lua.LTFunction {
+		L.Close()
+		return fmt.Errorf("script does not define a function apply(metric)")
+	}
+	l.state = L
+	return nil
+}
+
+func (l *Lua) apply(m telegraf.Metric) ([]telegraf.Metric, error) {
+	L := l.state
+	err := l.withTimeout(L, func() error {
+		return L.CallByParam(lua.P{
+			Fn:      L.GetGlobal("apply"),
+			NRet:    1,
+			Protect: true,
+		}, l.toTable(m))
+	})
+	if err != nil {
+		return nil, err
+	}
+	ret := L.Get(-1)
+	L.Pop(1)
+
+	switch v := ret.(type) {
+	case *lua.LNilType:
+		return nil, nil
+	case *lua.LTable:
+		if v.RawGetString("name") != lua.LNil {
+			out, err := fromTable(v, m)
+			if err != nil {
+				return nil, err
+			}
+			return []telegraf.Metric{out}, nil
+		}
+
+		var metrics []telegraf.Metric
+		for i := 1; i <= v.Len(); i++ {
+			t, ok := v.RawGetInt(i).(*lua.LTable)
+			if !ok {
+				return nil, fmt.Errorf("apply returned a list with a non-table element")
+			}
+			out, err := fromTable(t, m)
+			if err != nil {
+				return nil, err
+			}
+			metrics = append(metrics, out)
+		}
+		return metrics, nil
+	}
+	return nil, fmt.Errorf("apply returned a %s instead of a table", ret.Type())
+}
+
+// withTimeout runs f, interrupting the script running in L once the timeout
+// is reached.
+func (l *Lua) withTimeout(L *lua.LState, f func() error) error {
+	if l.Timeout.Duration <= 0 {
+		return f()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), l.Timeout.Duration)
+	defer cancel()
+	L.SetContext(ctx)
+	defer L.RemoveContext()
+
+	err := f()
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("script timed out after %s", l.Timeout.Duration)
+	}
+	return err
+}
+
+func (l *Lua) toTable(m telegraf.Metric) *lua.LTable {
+	L := l.state
+
+	tags := L.NewTable()
+	for k, v := range m.Tags() {
+		tags.RawSetString(k, lua.LString(v))
+	}
+
+	fields := L.NewTable()
+	for k, v := range m.Fields() {
+		switch v := v.(type) {
+		case float64:
+			fields.RawSetString(k, lua.LNumber(v))
+		case int64:
+			fields.RawSetString(k, lua.LNumber(v))
+		case string:
+			fields.RawSetString(k, lua.LString(v))
+		case bool:
+			fields.RawSetString(k, lua.LBool(v))
+		}
+	}
+
+	t := L.NewTable()
+	t.RawSetString("name", lua.LString(m.Name()))
+	t.RawSetString("tags", tags)
+	t.RawSetString("fields", fields)
+	t.RawSetString("time", lua.LNumber(m.UnixNano()))
+	t.RawSetString("type", lua.LString(typeName(m.Type())))
+	return t
+}
+
+// fromTable converts a table returned by the script into a metric. As Lua
+// only has float numbers, fields that were integers on the original metric
+// stay integers if their value is integral, and keep their original value
+// unless the script changed it. The time of the original metric is used
+// unless the script changed it.
+func fromTable(t *lua.LTable, orig telegraf.Metric) (telegraf.Metric, error) {
+	name, ok := t.RawGetString("name").(lua.LString)
+	if !ok || name == "" {
+		return nil, fmt.Errorf("metric name must be a non-empty string")
+	}
+
+	tags := make(map[string]string)
+	if tt, ok := t.RawGetString("tags").(*lua.LTable); ok {
+		tt.ForEach(func(k, v lua.LValue) {
+			tags[k.String()] = v.String()
+		})
+	}
+
+	origFields := orig.Fields()
+	fields := make(map[string]interface{})
+	if ft, ok := t.RawGetString("fields").(*lua.LTable); ok {
+		ft.ForEach(func(k, v lua.LValue) {
+			key := k.String()
+			switch v := v.(type) {
+			case lua.LNumber:
+				f := float64(v)
+				i, isInt := origFields[key].(int64)
+				switch {
+				case isInt && f == float64(i):
+					// unchanged, keep the integer beyond the float
+					// precision
+					fields[key] = i
+				case isInt && f == math.Trunc(f):
+					fields[key] = int64(f)
+				default:
+					fields[key] = f
+				}
+			case lua.LString:
+				fields[key] = string(v)
+			case lua.LBool:
+				fields[key] = bool(v)
+			}
+		})
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("metric %s has no fields", name)
+	}
+
+	tm := orig.Time()
+	if n, ok := t.RawGetString("time").(lua.LNumber); ok &&
+		float64(n) != float64(orig.UnixNano()) {
+		tm = time.Unix(0, int64(n))
+	}
+
+	mType := orig.Type()
+	if s, ok := t.RawGetString("type").(lua.LString); ok {
+		switch s {
+		case "counter":
+			mType = telegraf.Counter
+		case "gauge":
+			mType = telegraf.Gauge
+		default:
+			mType = telegraf.Untyped
+		}
+	}
+
+	return telegraf.NewTypedMetric(string(name), tags, fields, tm, mType)
+}
+
+func typeName(t telegraf.ValueType) string {
+	switch t {
+	case telegraf.Counter:
+		return "counter"
+	case telegraf.Gauge:
+		return "gauge"
+	default:
+		return "untyped"
+	}
+}
+
+func init() {
+	processors.Add("lua", func() telegraf.Processor {
+		return &Lua{
+			Timeout: internal.Duration{Duration: time.Second},
+		}
+	})
+}
@@ -0,0 +1,161 @@
+package lua
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMem() telegraf.Metric {
+	m, _ := telegraf.NewGaugeMetric("mem",
+		map[string]string{"host": "web01"},
+		map[string]interface{}{
+			"used":   int64(25),
+			"total":  int64(100),
+			"status": "ok",
+		},
+		time.Unix(0, 1479822000123456789),
+	)
+	return m
+}
+
+func TestModify(t *testing.T) {
+	l := &Lua{Source: `
+function apply(metric)
+  metric.fields.used_percent = metric.fields.used / metric.fields.total * 100
+  metric.fields.used = metric.fields.used * 2
+  metric.tags.team = "web"
+  metric.tags.host = nil
+  return metric
+end
+`}
+	out := l.Apply(newMem())
+	require.Len(t, out, 1)
+
+	assert.Equal(t, "mem", out[0].Name())
+	assert.Equal(t, map[string]string{"team": "web"}, out[0].Tags())
+	assert.Equal(t, map[string]interface{}{
+		"used":         int64(50),
+		"total":        int64(100),
+		"used_percent": float64(25),
+		"status":       "ok",
+	}, out[0].Fields())
+	assert.Equal(t, int64(1479822000123456789), out[0].UnixNano())
+	assert.Equal(t, telegraf.Gauge, out[0].Type())
+}
+
+func TestDropAndSplit(t *testing.T) {
+	l := &Lua{Source: `
+function apply(metric)
+  if metric.tags.host == "web01" then
+    return nil
+  end
+  return {
+    {name = metric.name .. "_used", fields = {value = metric.fields.used}},
+    {name = metric.name .. "_total", fields = {value = metric.fields.total}, type = "counter"},
+  }
+end
+`}
+	assert.Len(t, l.Apply(newMem()), 0)
+
+	m, _ := telegraf.NewMetric("mem",
+		map[string]string{"host": "db01"},
+		map[string]interface{}{"used": float64(1), "total": float64(2)},
+		time.Now(),
+	)
+	out := l.Apply(m)
+	require.Len(t, out, 2)
+	assert.Equal(t, "mem_used", out[0].Name())
+	assert.Equal(t, map[string]interface{}{"value": float64(1)}, out[0].Fields())
+	assert.Equal(t, telegraf.Untyped, out[0].Type())
+	assert.Equal(t, "mem_total", out[1].Name())
+	assert.Equal(t, telegraf.Counter, out[1].Type())
+	assert.Equal(t, m.Time(), out[1].Time())
+}
+
+func TestState(t *testing.T) {
+	l := &Lua{Source: `
+function apply(metric)
+  state.count = (state.count or 0) + 1
+  metric.fields.count = state.count
+  return metric
+end
+`}
+	l.Apply(newMem())
+	out := l.Apply(newMem())
+	assert.Equal(t, float64(2), out[0].Fields()["count"])
+}
+
+func TestSandbox(t *testing.T) {
+	for _, source := range []string{
+		`os.exit(1)`,
+		`io.open("/etc/passwd")`,
+		`dofile("/etc/passwd")`,
+		`function notapply(metric) return metric end`,
+	} {
+		l := &Lua{Source: source}
+		assert.Error(t, l.load(), source)
+	}
+}
+
+func TestRuntimeErrorPassesMetric(t *testing.T) {
+	l := &Lua{Source: `
+function apply(metric)
+  error("boom")
+end
+`}
+	m := newMem()
+	out := l.Apply(m)
+	require.Len(t, out, 1)
+	assert.Equal(t, m, out[0])
+}
+
+func TestLargeIntegerUnchanged(t *testing.T) {
+	l := &Lua{Source: `
+function apply(metric)
+  metric.fields.other = 1
+  return metric
+end
+`}
+	m, _ := telegraf.NewMetric("bytes", nil,
+		map[string]interface{}{"total": int64(9007199254740993)},
+		time.Unix(0, 0))
+	out := l.Apply(m)
+	require.Len(t, out, 1)
+	assert.Equal(t, map[string]interface{}{
+		"total": int64(9007199254740993),
+		"other": float64(1),
+	}, out[0].Fields())
+}
+
+func TestTimeoutPassesMetric(t *testing.T) {
+	l := &Lua{
+		Source: `
+function apply(metric)
+  while true do end
+end
+`,
+		Timeout: internal.Duration{Duration: 100 * time.Millisecond},
+	}
+	m := newMem()
+	out := l.Apply(m)
+	require.Len(t, out, 1)
+	assert.Equal(t, m, out[0])
+
+	// the interpreter is still usable after a timeout
+	out = l.Apply(m)
+	require.Len(t, out, 1)
+	assert.Equal(t, m, out[0])
+}
+
+func TestTimeoutLoad(t *testing.T) {
+	l := &Lua{
+		Source:  `while true do end`,
+		Timeout: internal.Duration{Duration: 100 * time.Millisecond},
+	}
+	assert.Error(t, l.load())
+}
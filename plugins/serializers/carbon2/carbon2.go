@@ -0,0 +1,100 @@
+package carbon2
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+)
+
+const (
+	// FieldSeparate names the samples after the measurement, with the field
+	// in an intrinsic tag of its own: "metric=cpu field=usage_idle".
+	FieldSeparate = "field_separate"
+	// MetricIncludesField names the samples after the measurement and the
+	// field: "metric=cpu_usage_idle".
+	MetricIncludesField = "metric_includes_field"
+)
+
+var sanitizedChars = strings.NewReplacer(" ", "_", "=", "_")
+
+// Carbon2Serializer serializes metrics into the Carbon 2.0 format, ie
+// "metric=cpu field=usage_idle cpu=cpu0  91.5 1479822000". The name, field
+// and tags are intrinsic tags, separated from the value by two spaces.
+// String fields are skipped and booleans written as 1 or 0.
+type Carbon2Serializer struct {
+	Format string
+}
+
+func NewCarbon2Serializer(format string) (*Carbon2Serializer, error) {
+	switch format {
+	case "":
+		format = FieldSeparate
+	case FieldSeparate, MetricIncludesField:
+	default:
+		return nil, fmt.Errorf("invalid carbon2 format %q, must be %s or %s",
+			format, FieldSeparate, MetricIncludesField)
+	}
+	return &Carbon2Serializer{Format: format}, nil
+}
+
+func (s *Carbon2Serializer) Serialize(metric telegraf.Metric) ([]string, error) {
+	out := []string{}
+
+	tags := serializeTags(metric.Tags())
+	timestamp := metric.UnixNano() / 1000000000
+
+	for fieldName, value := range metric.Fields() {
+		valueS, ok := formatValue(value)
+		if !ok {
+			continue
+		}
+
+		var intrinsic string
+		if s.Format == MetricIncludesField {
+			intrinsic = "metric=" + sanitizedChars.Replace(metric.Name()+"_"+fieldName)
+		} else {
+			intrinsic = "metric=" + sanitizedChars.Replace(metric.Name()) +
+				" field=" + sanitizedChars.Replace(fieldName)
+		}
+
+		out = append(out, fmt.Sprintf("%s%s  %s %d", intrinsic, tags, valueS, timestamp))
+	}
+	return out, nil
+}
+
+// serializeTags returns the tags sorted by key, each preceded by a space.
+// Tags with an empty key or value are skipped.
+func serializeTags(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for k, v := range tags {
+		if k == "" || v == "" {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var s string
+	for _, k := range keys {
+		s += " " + sanitizedChars.Replace(k) + "=" + sanitizedChars.Replace(tags[k])
+	}
+	return s
+}
+
+func formatValue(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case bool:
+		if v {
+			return "1", true
+		}
+		return "0", true
+	}
+	return "", false
+}
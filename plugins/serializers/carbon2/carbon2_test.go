@@ -0,0 +1,52 @@
+package carbon2
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+)
+
+func TestSerializeFieldSeparate(t *testing.T) {
+	m, err := telegraf.NewMetric("cpu",
+		map[string]string{"cpu": "cpu0", "host": "web 01"},
+		map[string]interface{}{"usage_idle": float64(91.5)},
+		time.Unix(1479822000, 0))
+	require.NoError(t, err)
+
+	s, err := NewCarbon2Serializer("")
+	require.NoError(t, err)
+	lines, err := s.Serialize(m)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"metric=cpu field=usage_idle cpu=cpu0 host=web_01  91.5 1479822000",
+	}, lines)
+}
+
+func TestSerializeMetricIncludesField(t *testing.T) {
+	m, err := telegraf.NewMetric("disk",
+		map[string]string{},
+		map[string]interface{}{
+			"free":     int64(42),
+			"readonly": true,
+			"mode":     "rw",
+		},
+		time.Unix(1479822000, 0))
+	require.NoError(t, err)
+
+	s, err := NewCarbon2Serializer(MetricIncludesField)
+	require.NoError(t, err)
+	lines, err := s.Serialize(m)
+	require.NoError(t, err)
+	assert.Len(t, lines, 2)
+	assert.Contains(t, lines, "metric=disk_free  42 1479822000")
+	assert.Contains(t, lines, "metric=disk_readonly  1 1479822000")
+}
+
+func TestInvalidFormat(t *testing.T) {
+	_, err := NewCarbon2Serializer("metric_and_field")
+	assert.Error(t, err)
+}
@@ -2,6 +2,7 @@ package graphite
 
 import (
 	"fmt"
+	"regexp"
 	"sort"
 	"strings"
 
@@ -13,11 +14,23 @@ const DEFAULT_TEMPLATE = "host.tags.measurement.field"
 var (
 	fieldDeleter   = strings.NewReplacer(".FIELDNAME", "", "FIELDNAME.", "")
 	sanitizedChars = strings.NewReplacer("/", "-", "@", "-", "*", "-", " ", "_", "..", ".", `\`, "", ")", "_", "(", "_")
+
+	// strictSanitize replaces the characters not allowed in the path of
+	// tagged series.
+	strictSanitize = regexp.MustCompile(`[^a-zA-Z0-9\-:._=\p{L}]`)
+	// tagKeySanitize and tagValueSanitize replace the characters not allowed
+	// in tag keys and values of tagged series.
+	tagKeySanitize   = regexp.MustCompile(`[;!^=\s]`)
+	tagValueSanitize = regexp.MustCompile(`[;\s]`)
 )
 
 type GraphiteSerializer struct {
 	Prefix   string
 	Template string
+
+	// TagSupport writes tagged series ("name;tag=value") instead of encoding
+	// the tags in the path with the template.
+	TagSupport bool
 }
 
 func (s *GraphiteSerializer) Serialize(metric telegraf.Metric) ([]string, error) {
@@ -26,6 +39,20 @@ func (s *GraphiteSerializer) Serialize(metric telegraf.Metric) ([]string, error)
 	// Convert UnixNano to Unix timestamps
 	timestamp := metric.UnixNano() / 1000000000
 
+	if s.TagSupport {
+		tags := SerializeTags(metric.Tags())
+		for fieldName, value := range metric.Fields() {
+			valueS := fmt.Sprintf("%#v", value)
+			point := fmt.Sprintf("%s%s %s %d",
+				SerializeTaggedName(metric.Name(), fieldName, s.Prefix),
+				tags,
+				sanitizedChars.Replace(valueS),
+				timestamp)
+			out = append(out, point)
+		}
+		return out, nil
+	}
+
 	bucket := SerializeBucketName(metric.Name(), metric.Tags(), s.Template, s.Prefix)
 	if bucket == "" {
 		return out, nil
@@ -134,3 +161,39 @@ func buildTags(tags map[string]string) string {
 	}
 	return tag_str
 }
+
+// SerializeTaggedName returns the path of a tagged series, joining the prefix,
+// measurement and field name. The field name is left out if it is "value".
+func SerializeTaggedName(measurement, fieldName, prefix string) string {
+	name := measurement
+	if fieldName != "value" {
+		name += "." + fieldName
+	}
+	if prefix != "" {
+		name = prefix + "." + name
+	}
+	return strictSanitize.ReplaceAllString(name, "_")
+}
+
+// SerializeTags returns the tags as ";key=value" pairs sorted by key, as
+// used by tagged series. Characters not allowed in tags are replaced with an
+// underscore, and the leading "~" of values, which marks a tag pattern in
+// graphite queries, is removed. Tags with an empty key or value are skipped.
+func SerializeTags(tags map[string]string) string {
+	var keys []string
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var out string
+	for _, k := range keys {
+		key := tagKeySanitize.ReplaceAllString(k, "_")
+		value := tagValueSanitize.ReplaceAllString(strings.TrimLeft(tags[k], "~"), "_")
+		if key == "" || value == "" {
+			continue
+		}
+		out += ";" + key + "=" + value
+	}
+	return out
+}
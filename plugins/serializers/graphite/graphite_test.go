@@ -406,3 +406,53 @@ func TestTemplate6(t *testing.T) {
 	expS := "localhost.cpu0.us-west-2.cpu.FIELDNAME"
 	assert.Equal(t, expS, mS)
 }
+
+func TestSerializeTagSupport(t *testing.T) {
+	now := time.Now()
+	tags := map[string]string{
+		"host":       "localhost",
+		"cpu":        "cpu0",
+		"datacenter": "us-west-2",
+	}
+	fields := map[string]interface{}{
+		"usage_idle": float64(91.5),
+		"value":      int64(1),
+	}
+	m, err := telegraf.NewMetric("cpu", tags, fields, now)
+	assert.NoError(t, err)
+
+	s := GraphiteSerializer{Prefix: "telegraf", TagSupport: true}
+	mS, err := s.Serialize(m)
+	assert.NoError(t, err)
+
+	expS := []string{
+		fmt.Sprintf("telegraf.cpu.usage_idle;cpu=cpu0;datacenter=us-west-2;host=localhost 91.5 %d", now.Unix()),
+		fmt.Sprintf("telegraf.cpu;cpu=cpu0;datacenter=us-west-2;host=localhost 1 %d", now.Unix()),
+	}
+	sort.Strings(mS)
+	sort.Strings(expS)
+	assert.Equal(t, expS, mS)
+}
+
+func TestSerializeTagSupportSanitize(t *testing.T) {
+	now := time.Now()
+	tags := map[string]string{
+		"tag;key=":  "~value with;spaces",
+		"empty":     "",
+		"unicode_ü": "ok",
+	}
+	fields := map[string]interface{}{
+		"field (total)": float64(1),
+	}
+	m, err := telegraf.NewMetric("my measurement", tags, fields, now)
+	assert.NoError(t, err)
+
+	s := GraphiteSerializer{TagSupport: true}
+	mS, err := s.Serialize(m)
+	assert.NoError(t, err)
+
+	expS := []string{
+		fmt.Sprintf("my_measurement.field__total_;tag_key_=value_with_spaces;unicode_ü=ok 1 %d", now.Unix()),
+	}
+	assert.Equal(t, expS, mS)
+}
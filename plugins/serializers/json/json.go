@@ -1,23 +1,50 @@
 package json
 
 import (
+	"bytes"
 	ejson "encoding/json"
+	"fmt"
+	"time"
 
 	"github.com/influxdata/telegraf"
 )
 
 type JsonSerializer struct {
+	// TimestampUnits is the precision of the timestamp, one second if zero.
+	TimestampUnits time.Duration
+	// Array serializes batches as a JSON array instead of one object per
+	// line.
+	Array bool
+	// Nesting is either "nested" to hold the fields and tags in objects of
+	// their own, or "flat" to put them next to the name and timestamp.
+	Nesting string
+}
+
+// NewJsonSerializer returns a JSON serializer, checking its options.
+func NewJsonSerializer(
+	timestampUnits time.Duration,
+	array bool,
+	nesting string,
+) (*JsonSerializer, error) {
+	switch nesting {
+	case "", "nested", "flat":
+	default:
+		return nil, fmt.Errorf("invalid json nesting %q, must be nested or flat", nesting)
+	}
+	if timestampUnits < 0 || timestampUnits > time.Second {
+		return nil, fmt.Errorf("invalid json timestamp units %s", timestampUnits)
+	}
+	return &JsonSerializer{
+		TimestampUnits: timestampUnits,
+		Array:          array,
+		Nesting:        nesting,
+	}, nil
 }
 
 func (s *JsonSerializer) Serialize(metric telegraf.Metric) ([]string, error) {
 	out := []string{}
 
-	m := make(map[string]interface{})
-	m["tags"] = metric.Tags()
-	m["fields"] = metric.Fields()
-	m["name"] = metric.Name()
-	m["timestamp"] = metric.UnixNano() / 1000000000
-	serialized, err := ejson.Marshal(m)
+	serialized, err := ejson.Marshal(s.object(metric))
 	if err != nil {
 		return []string{}, err
 	}
@@ -25,3 +52,55 @@ func (s *JsonSerializer) Serialize(metric telegraf.Metric) ([]string, error) {
 
 	return out, nil
 }
+
+// SerializeBatch serializes the metrics as a JSON array, or as one object
+// per line.
+func (s *JsonSerializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	if s.Array {
+		objects := make([]map[string]interface{}, 0, len(metrics))
+		for _, metric := range metrics {
+			objects = append(objects, s.object(metric))
+		}
+		serialized, err := ejson.Marshal(objects)
+		if err != nil {
+			return nil, err
+		}
+		return append(serialized, '\n'), nil
+	}
+
+	var buf bytes.Buffer
+	for _, metric := range metrics {
+		serialized, err := ejson.Marshal(s.object(metric))
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(serialized)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+// object returns the metric as a map to be marshalled. In the flat style,
+// fields take precedence over tags of the same name.
+func (s *JsonSerializer) object(metric telegraf.Metric) map[string]interface{} {
+	units := s.TimestampUnits
+	if units <= 0 {
+		units = time.Second
+	}
+
+	m := make(map[string]interface{})
+	if s.Nesting == "flat" {
+		for k, v := range metric.Tags() {
+			m[k] = v
+		}
+		for k, v := range metric.Fields() {
+			m[k] = v
+		}
+	} else {
+		m["tags"] = metric.Tags()
+		m["fields"] = metric.Fields()
+	}
+	m["name"] = metric.Name()
+	m["timestamp"] = metric.UnixNano() / int64(units)
+	return m
+}
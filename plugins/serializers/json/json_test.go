@@ -85,3 +85,58 @@ func TestSerializeMultiFields(t *testing.T) {
 	expS := []string{fmt.Sprintf("{\"fields\":{\"usage_idle\":90,\"usage_total\":8559615},\"name\":\"cpu\",\"tags\":{\"cpu\":\"cpu0\"},\"timestamp\":%d}", now.Unix())}
 	assert.Equal(t, expS, mS)
 }
+
+func TestSerializeBatchArray(t *testing.T) {
+	now := time.Unix(1479822000, 123456789)
+	m1, err := telegraf.NewMetric("cpu",
+		map[string]string{"cpu": "cpu0"},
+		map[string]interface{}{"usage_idle": float64(91.5)}, now)
+	assert.NoError(t, err)
+	m2, err := telegraf.NewMetric("mem",
+		map[string]string{},
+		map[string]interface{}{"free": int64(42)}, now)
+	assert.NoError(t, err)
+
+	s, err := NewJsonSerializer(time.Millisecond, true, "")
+	assert.NoError(t, err)
+	buf, err := s.SerializeBatch([]telegraf.Metric{m1, m2})
+	assert.NoError(t, err)
+	assert.Equal(t, "[{\"fields\":{\"usage_idle\":91.5},\"name\":\"cpu\",\"tags\":{\"cpu\":\"cpu0\"},\"timestamp\":1479822000123},"+
+		"{\"fields\":{\"free\":42},\"name\":\"mem\",\"tags\":{},\"timestamp\":1479822000123}]\n", string(buf))
+}
+
+func TestSerializeBatchLines(t *testing.T) {
+	now := time.Unix(1479822000, 0)
+	m, err := telegraf.NewMetric("cpu",
+		map[string]string{"cpu": "cpu0"},
+		map[string]interface{}{"usage_idle": float64(91.5)}, now)
+	assert.NoError(t, err)
+
+	s, err := NewJsonSerializer(0, false, "")
+	assert.NoError(t, err)
+	buf, err := s.SerializeBatch([]telegraf.Metric{m, m})
+	assert.NoError(t, err)
+	line := "{\"fields\":{\"usage_idle\":91.5},\"name\":\"cpu\",\"tags\":{\"cpu\":\"cpu0\"},\"timestamp\":1479822000}\n"
+	assert.Equal(t, line+line, string(buf))
+}
+
+func TestSerializeFlat(t *testing.T) {
+	now := time.Unix(1479822000, 0)
+	m, err := telegraf.NewMetric("cpu",
+		map[string]string{"cpu": "cpu0"},
+		map[string]interface{}{"usage_idle": float64(91.5)}, now)
+	assert.NoError(t, err)
+
+	s, err := NewJsonSerializer(time.Nanosecond, false, "flat")
+	assert.NoError(t, err)
+	mS, err := s.Serialize(m)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"{\"cpu\":\"cpu0\",\"name\":\"cpu\",\"timestamp\":1479822000000000000,\"usage_idle\":91.5}"}, mS)
+}
+
+func TestInvalidOptions(t *testing.T) {
+	_, err := NewJsonSerializer(0, false, "deep")
+	assert.Error(t, err)
+	_, err = NewJsonSerializer(time.Minute, false, "")
+	assert.Error(t, err)
+}
@@ -0,0 +1,174 @@
+package msgpack
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"sort"
+
+	"github.com/influxdata/telegraf"
+)
+
+// MsgpackSerializer serializes every metric into a MessagePack map:
+//
+//	{"name": "cpu", "time": 1479822000000000000, "type": "gauge",
+//	 "tags": {"cpu": "cpu0"}, "fields": {"usage_idle": 91.5}}
+//
+// The time is in nanoseconds and the type is one of "counter", "gauge" or
+// "untyped". Integer fields are encoded as integers and float fields as
+// 64 bits floats, so that the types of the fields are kept. Batches are the
+// concatenation of their maps.
+type MsgpackSerializer struct{}
+
+func (s *MsgpackSerializer) Serialize(metric telegraf.Metric) ([]string, error) {
+	var buf bytes.Buffer
+	writeMetric(&buf, metric)
+	return []string{buf.String()}, nil
+}
+
+func (s *MsgpackSerializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, metric := range metrics {
+		writeMetric(&buf, metric)
+	}
+	return buf.Bytes(), nil
+}
+
+func writeMetric(buf *bytes.Buffer, metric telegraf.Metric) {
+	writeMapHeader(buf, 5)
+
+	writeString(buf, "name")
+	writeString(buf, metric.Name())
+
+	writeString(buf, "time")
+	writeInt(buf, metric.UnixNano())
+
+	writeString(buf, "type")
+	switch metric.Type() {
+	case telegraf.Counter:
+		writeString(buf, "counter")
+	case telegraf.Gauge:
+		writeString(buf, "gauge")
+	default:
+		writeString(buf, "untyped")
+	}
+
+	tags := metric.Tags()
+	writeString(buf, "tags")
+	writeMapHeader(buf, len(tags))
+	for _, k := range sortedKeys(tags) {
+		writeString(buf, k)
+		writeString(buf, tags[k])
+	}
+
+	fields := metric.Fields()
+	keys := make([]string, 0, len(fields))
+	for k, v := range fields {
+		switch v.(type) {
+		case int64, float64, string, bool:
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	writeString(buf, "fields")
+	writeMapHeader(buf, len(keys))
+	for _, k := range keys {
+		writeString(buf, k)
+		switch v := fields[k].(type) {
+		case int64:
+			writeInt(buf, v)
+		case float64:
+			buf.WriteByte(0xcb)
+			writeUint64(buf, math.Float64bits(v))
+		case string:
+			writeString(buf, v)
+		case bool:
+			if v {
+				buf.WriteByte(0xc3)
+			} else {
+				buf.WriteByte(0xc2)
+			}
+		}
+	}
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func writeMapHeader(buf *bytes.Buffer, n int) {
+	switch {
+	case n < 16:
+		buf.WriteByte(0x80 | byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(0xde)
+		writeUint16(buf, uint16(n))
+	default:
+		buf.WriteByte(0xdf)
+		writeUint32(buf, uint32(n))
+	}
+}
+
+func writeString(buf *bytes.Buffer, s string) {
+	n := len(s)
+	switch {
+	case n < 32:
+		buf.WriteByte(0xa0 | byte(n))
+	case n <= math.MaxUint8:
+		buf.WriteByte(0xd9)
+		buf.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(0xda)
+		writeUint16(buf, uint16(n))
+	default:
+		buf.WriteByte(0xdb)
+		writeUint32(buf, uint32(n))
+	}
+	buf.WriteString(s)
+}
+
+// writeInt writes an integer in its shortest encoding.
+func writeInt(buf *bytes.Buffer, i int64) {
+	switch {
+	case i >= 0 && i <= math.MaxInt8:
+		buf.WriteByte(byte(i))
+	case i < 0 && i >= -32:
+		buf.WriteByte(byte(int8(i)))
+	case i >= math.MinInt8 && i <= math.MaxInt8:
+		buf.WriteByte(0xd0)
+		buf.WriteByte(byte(int8(i)))
+	case i >= math.MinInt16 && i <= math.MaxInt16:
+		buf.WriteByte(0xd1)
+		writeUint16(buf, uint16(int16(i)))
+	case i >= math.MinInt32 && i <= math.MaxInt32:
+		buf.WriteByte(0xd2)
+		writeUint32(buf, uint32(int32(i)))
+	default:
+		buf.WriteByte(0xd3)
+		writeUint64(buf, uint64(i))
+	}
+}
+
+func writeUint16(buf *bytes.Buffer, v uint16) {
+	var b [2]byte
+	binary.BigEndian.PutUint16(b[:], v)
+	buf.Write(b[:])
+}
+
+func writeUint32(buf *bytes.Buffer, v uint32) {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], v)
+	buf.Write(b[:])
+}
+
+func writeUint64(buf *bytes.Buffer, v uint64) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], v)
+	buf.Write(b[:])
+}
@@ -0,0 +1,223 @@
+package prometheus
+
+import (
+	"bytes"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+)
+
+var (
+	invalidNameCharRE  = regexp.MustCompile(`[^a-zA-Z0-9_:]`)
+	invalidLabelCharRE = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+	labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+)
+
+// PrometheusSerializer serializes metrics into the Prometheus text exposition
+// format, or into the OpenMetrics text format. Every numeric field becomes a
+// sample named after the measurement and the field, with the tags as labels.
+// String and boolean fields are skipped.
+type PrometheusSerializer struct {
+	// ExportTimestamp adds the time of the metrics to the samples.
+	ExportTimestamp bool
+	// OpenMetrics writes the OpenMetrics text format: counters are suffixed
+	// with _total, timestamps are in seconds and batches end with "# EOF".
+	OpenMetrics bool
+}
+
+// family is the set of samples sharing a metric name.
+type family struct {
+	name    string
+	mType   telegraf.ValueType
+	samples map[string]*sample
+}
+
+type sample struct {
+	labels string
+	value  float64
+	time   int64
+}
+
+// Serialize writes the samples of a single metric. TYPE lines are left out,
+// as outputs serializing metric by metric would repeat them for every
+// metric; in the OpenMetrics format, the "# EOF" line is left out as well.
+// Both are only written by SerializeBatch.
+func (s *PrometheusSerializer) Serialize(metric telegraf.Metric) ([]string, error) {
+	return s.lines(s.families([]telegraf.Metric{metric}), false), nil
+}
+
+// SerializeBatch groups the samples of the metrics by metric name, so that
+// every metric family is described by a single TYPE line. When a series
+// occurs more than once, the latest sample is kept.
+func (s *PrometheusSerializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, line := range s.lines(s.families(metrics), true) {
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+	}
+	if s.OpenMetrics {
+		buf.WriteString("# EOF\n")
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *PrometheusSerializer) families(metrics []telegraf.Metric) []*family {
+	families := make(map[string]*family)
+	var names []string
+
+	for _, metric := range metrics {
+		labels := labels(metric.Tags())
+		for k, v := range metric.Fields() {
+			var value float64
+			switch v := v.(type) {
+			case int64:
+				value = float64(v)
+			case float64:
+				value = v
+			default:
+				continue
+			}
+
+			name := MetricName(metric.Name(), k)
+			f, ok := families[name]
+			if !ok {
+				f = &family{
+					name:    name,
+					mType:   metric.Type(),
+					samples: make(map[string]*sample),
+				}
+				families[name] = f
+				names = append(names, name)
+			} else if f.mType != metric.Type() {
+				f.mType = telegraf.Untyped
+			}
+
+			if old, ok := f.samples[labels]; ok && old.time > metric.UnixNano() {
+				continue
+			}
+			f.samples[labels] = &sample{
+				labels: labels,
+				value:  value,
+				time:   metric.UnixNano(),
+			}
+		}
+	}
+
+	sort.Strings(names)
+	result := make([]*family, 0, len(names))
+	for _, name := range names {
+		result = append(result, families[name])
+	}
+	return result
+}
+
+// lines returns the samples of the families, preceded by a TYPE line per
+// family if withType is set.
+func (s *PrometheusSerializer) lines(families []*family, withType bool) []string {
+	var out []string
+	for _, f := range families {
+		name := f.name
+		if s.OpenMetrics && f.mType == telegraf.Counter {
+			name = strings.TrimSuffix(name, "_total")
+		}
+		if withType {
+			out = append(out, "# TYPE "+name+" "+s.typeName(f.mType))
+		}
+
+		keys := make([]string, 0, len(f.samples))
+		for k := range f.samples {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			sample := f.samples[k]
+			line := name
+			if s.OpenMetrics && f.mType == telegraf.Counter {
+				line += "_total"
+			}
+			line += sample.labels + " " + formatFloat(sample.value)
+			if s.ExportTimestamp {
+				if s.OpenMetrics {
+					line += " " + strconv.FormatFloat(float64(sample.time)/1e9, 'f', -1, 64)
+				} else {
+					line += " " + strconv.FormatInt(sample.time/1e6, 10)
+				}
+			}
+			out = append(out, line)
+		}
+	}
+	return out
+}
+
+func (s *PrometheusSerializer) typeName(mType telegraf.ValueType) string {
+	switch mType {
+	case telegraf.Counter:
+		return "counter"
+	case telegraf.Gauge:
+		return "gauge"
+	}
+	if s.OpenMetrics {
+		return "unknown"
+	}
+	return "untyped"
+}
+
+// MetricName returns the sanitized name of the samples of a field. Fields
+// named "value" are named after the measurement only.
+func MetricName(measurement, field string) string {
+	name := measurement
+	if field != "value" {
+		name += "_" + field
+	}
+	name = invalidNameCharRE.ReplaceAllString(name, "_")
+	if len(name) > 0 && name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+	return name
+}
+
+// LabelName returns the sanitized label name of a tag key.
+func LabelName(key string) string {
+	name := invalidLabelCharRE.ReplaceAllString(key, "_")
+	if len(name) > 0 && name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+	return name
+}
+
+// labels returns the tags as a sorted and escaped label set, ie
+// `{host="a",region="b"}`. Tags with an empty key or value are skipped.
+func labels(tags map[string]string) string {
+	values := make(map[string]string, len(tags))
+	for k, v := range tags {
+		k = LabelName(k)
+		if k == "" || v == "" {
+			continue
+		}
+		values[k] = v
+	}
+	if len(values) == 0 {
+		return ""
+	}
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, k+`="`+labelValueEscaper.Replace(values[k])+`"`)
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
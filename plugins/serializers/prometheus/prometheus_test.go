@@ -0,0 +1,100 @@
+package prometheus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+)
+
+var testTime = time.Unix(1479822000, 500000000)
+
+func TestSerializeMetric(t *testing.T) {
+	m, err := telegraf.NewGaugeMetric("cpu",
+		map[string]string{"cpu": "cpu0", "host.name": "web01"},
+		map[string]interface{}{
+			"usage_idle": float64(91.5),
+			"status":     "ok",
+		},
+		testTime)
+	require.NoError(t, err)
+
+	s := PrometheusSerializer{}
+	lines, err := s.Serialize(m)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		`cpu_usage_idle{cpu="cpu0",host_name="web01"} 91.5`,
+	}, lines)
+}
+
+func TestSerializeBatch(t *testing.T) {
+	m1, err := telegraf.NewCounterMetric("net",
+		map[string]string{"interface": "eth0"},
+		map[string]interface{}{"bytes_recv": int64(1024)},
+		testTime)
+	require.NoError(t, err)
+	m2, err := telegraf.NewCounterMetric("net",
+		map[string]string{"interface": "eth1"},
+		map[string]interface{}{"bytes_recv": int64(2048)},
+		testTime)
+	require.NoError(t, err)
+	m3, err := telegraf.NewMetric("1m.load",
+		map[string]string{"path": "C:\\ \"x\"\n"},
+		map[string]interface{}{"value": float64(0.5)},
+		testTime)
+	require.NoError(t, err)
+
+	s := PrometheusSerializer{ExportTimestamp: true}
+	buf, err := s.SerializeBatch([]telegraf.Metric{m1, m2, m3})
+	require.NoError(t, err)
+	assert.Equal(t, "# TYPE _1m_load untyped\n"+
+		`_1m_load{path="C:\\ \"x\"\n"} 0.5 1479822000500`+"\n"+
+		"# TYPE net_bytes_recv counter\n"+
+		`net_bytes_recv{interface="eth0"} 1024 1479822000500`+"\n"+
+		`net_bytes_recv{interface="eth1"} 2048 1479822000500`+"\n",
+		string(buf))
+}
+
+func TestSerializeBatchLatestSample(t *testing.T) {
+	m1, err := telegraf.NewGaugeMetric("mem",
+		map[string]string{},
+		map[string]interface{}{"free": int64(2)},
+		testTime.Add(time.Second))
+	require.NoError(t, err)
+	m2, err := telegraf.NewGaugeMetric("mem",
+		map[string]string{},
+		map[string]interface{}{"free": int64(1)},
+		testTime)
+	require.NoError(t, err)
+
+	s := PrometheusSerializer{}
+	buf, err := s.SerializeBatch([]telegraf.Metric{m1, m2})
+	require.NoError(t, err)
+	assert.Equal(t, "# TYPE mem_free gauge\nmem_free 2\n", string(buf))
+}
+
+func TestSerializeOpenMetrics(t *testing.T) {
+	m1, err := telegraf.NewCounterMetric("http",
+		map[string]string{"code": "200"},
+		map[string]interface{}{"requests_total": int64(42)},
+		testTime)
+	require.NoError(t, err)
+	m2, err := telegraf.NewMetric("temp",
+		map[string]string{},
+		map[string]interface{}{"value": float64(21)},
+		testTime)
+	require.NoError(t, err)
+
+	s := PrometheusSerializer{ExportTimestamp: true, OpenMetrics: true}
+	buf, err := s.SerializeBatch([]telegraf.Metric{m1, m2})
+	require.NoError(t, err)
+	assert.Equal(t, "# TYPE http_requests counter\n"+
+		`http_requests_total{code="200"} 42 1479822000.5`+"\n"+
+		"# TYPE temp unknown\n"+
+		"temp 21 1479822000.5\n"+
+		"# EOF\n",
+		string(buf))
+}
@@ -0,0 +1,60 @@
+package protobuf
+
+import (
+	"github.com/golang/protobuf/proto"
+)
+
+// The message types of metric.proto, encoded by the reflection based
+// marshaller of golang/protobuf.
+
+type ValueType int32
+
+const (
+	ValueType_UNTYPED ValueType = 0
+	ValueType_COUNTER ValueType = 1
+	ValueType_GAUGE   ValueType = 2
+)
+
+var ValueType_name = map[int32]string{
+	0: "UNTYPED",
+	1: "COUNTER",
+	2: "GAUGE",
+}
+var ValueType_value = map[string]int32{
+	"UNTYPED": 0,
+	"COUNTER": 1,
+	"GAUGE":   2,
+}
+
+func (x ValueType) String() string {
+	return proto.EnumName(ValueType_name, int32(x))
+}
+
+type Batch struct {
+	Metrics []*Metric `protobuf:"bytes,1,rep,name=metrics" json:"metrics,omitempty"`
+}
+
+func (m *Batch) Reset()         { *m = Batch{} }
+func (m *Batch) String() string { return proto.CompactTextString(m) }
+func (*Batch) ProtoMessage()    {}
+
+type Metric struct {
+	Name         string             `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Tags         map[string]string  `protobuf:"bytes,2,rep,name=tags" json:"tags,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	FloatFields  map[string]float64 `protobuf:"bytes,3,rep,name=float_fields,json=floatFields" json:"float_fields,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"fixed64,2,opt,name=value,proto3"`
+	IntFields    map[string]int64   `protobuf:"bytes,4,rep,name=int_fields,json=intFields" json:"int_fields,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"zigzag64,2,opt,name=value,proto3"`
+	StringFields map[string]string  `protobuf:"bytes,5,rep,name=string_fields,json=stringFields" json:"string_fields,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	BoolFields   map[string]bool    `protobuf:"bytes,6,rep,name=bool_fields,json=boolFields" json:"bool_fields,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+	Time         int64              `protobuf:"varint,7,opt,name=time,proto3" json:"time,omitempty"`
+	Type         ValueType          `protobuf:"varint,8,opt,name=type,proto3,enum=telegraf.ValueType" json:"type,omitempty"`
+}
+
+func (m *Metric) Reset()         { *m = Metric{} }
+func (m *Metric) String() string { return proto.CompactTextString(m) }
+func (*Metric) ProtoMessage()    {}
+
+func init() {
+	proto.RegisterType((*Batch)(nil), "telegraf.Batch")
+	proto.RegisterType((*Metric)(nil), "telegraf.Metric")
+	proto.RegisterEnum("telegraf.ValueType", ValueType_name, ValueType_value)
+}
@@ -0,0 +1,71 @@
+package protobuf
+
+import (
+	"github.com/golang/protobuf/proto"
+
+	"github.com/influxdata/telegraf"
+)
+
+// ProtobufSerializer serializes metrics into Batch messages of metric.proto,
+// keeping the types of the fields and the value type of the metrics.
+type ProtobufSerializer struct{}
+
+// Serialize returns a Batch message of the single metric, so that the
+// messages of a stream of metrics can be concatenated.
+func (s *ProtobufSerializer) Serialize(metric telegraf.Metric) ([]string, error) {
+	buf, err := s.SerializeBatch([]telegraf.Metric{metric})
+	if err != nil {
+		return []string{}, err
+	}
+	return []string{string(buf)}, nil
+}
+
+func (s *ProtobufSerializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	batch := &Batch{Metrics: make([]*Metric, 0, len(metrics))}
+	for _, metric := range metrics {
+		batch.Metrics = append(batch.Metrics, NewMetric(metric))
+	}
+	return proto.Marshal(batch)
+}
+
+// NewMetric returns the message of a metric.
+func NewMetric(metric telegraf.Metric) *Metric {
+	m := &Metric{
+		Name: metric.Name(),
+		Tags: metric.Tags(),
+		Time: metric.UnixNano(),
+	}
+
+	switch metric.Type() {
+	case telegraf.Counter:
+		m.Type = ValueType_COUNTER
+	case telegraf.Gauge:
+		m.Type = ValueType_GAUGE
+	}
+
+	for k, v := range metric.Fields() {
+		switch v := v.(type) {
+		case float64:
+			if m.FloatFields == nil {
+				m.FloatFields = make(map[string]float64)
+			}
+			m.FloatFields[k] = v
+		case int64:
+			if m.IntFields == nil {
+				m.IntFields = make(map[string]int64)
+			}
+			m.IntFields[k] = v
+		case string:
+			if m.StringFields == nil {
+				m.StringFields = make(map[string]string)
+			}
+			m.StringFields[k] = v
+		case bool:
+			if m.BoolFields == nil {
+				m.BoolFields = make(map[string]bool)
+			}
+			m.BoolFields[k] = v
+		}
+	}
+	return m
+}
@@ -1,11 +1,19 @@
 package serializers
 
 import (
+	"bytes"
+	"time"
+
 	"github.com/influxdata/telegraf"
 
+	"github.com/influxdata/telegraf/plugins/serializers/carbon2"
 	"github.com/influxdata/telegraf/plugins/serializers/graphite"
 	"github.com/influxdata/telegraf/plugins/serializers/influx"
 	"github.com/influxdata/telegraf/plugins/serializers/json"
+	"github.com/influxdata/telegraf/plugins/serializers/msgpack"
+	"github.com/influxdata/telegraf/plugins/serializers/prometheus"
+	"github.com/influxdata/telegraf/plugins/serializers/protobuf"
+	"github.com/influxdata/telegraf/plugins/serializers/wavefront"
 )
 
 // SerializerOutput is an interface for output plugins that are able to
@@ -22,18 +30,90 @@ type Serializer interface {
 	Serialize(metric telegraf.Metric) ([]string, error)
 }
 
+// BatchSerializer is a Serializer which is able to turn a whole batch of
+// metrics into a single payload, ie a JSON array.
+type BatchSerializer interface {
+	Serializer
+
+	// SerializeBatch takes a batch of telegraf metrics and turns them into
+	// a single payload.
+	SerializeBatch(metrics []telegraf.Metric) ([]byte, error)
+}
+
+// NewBatchSerializer returns the serializer if it supports batches, or else
+// an adapter serializing the metrics one by one, each string on its own line.
+func NewBatchSerializer(serializer Serializer) BatchSerializer {
+	if bs, ok := serializer.(BatchSerializer); ok {
+		return bs
+	}
+	return &lineSerializer{serializer}
+}
+
+type lineSerializer struct {
+	Serializer
+}
+
+func (s *lineSerializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, metric := range metrics {
+		values, err := s.Serialize(metric)
+		if err != nil {
+			return nil, err
+		}
+		for _, value := range values {
+			buf.WriteString(value)
+			buf.WriteByte('\n')
+		}
+	}
+	return buf.Bytes(), nil
+}
+
 // Config is a struct that covers the data types needed for all serializer types,
 // and can be used to instantiate _any_ of the serializers.
 type Config struct {
-	// Dataformat can be one of: influx, graphite
+	// Dataformat can be one of: influx, graphite, json, prometheus, carbon2,
+	// wavefront, msgpack, protobuf
 	DataFormat string
 
-	// Prefix to add to all measurements, only supports Graphite
+	// Prefix to add to all measurements, only supports Graphite and Wavefront
 	Prefix string
 
 	// Template for converting telegraf metrics into Graphite
 	// only supports Graphite
 	Template string
+
+	// GraphiteTagSupport writes Graphite tagged series instead of using
+	// the template, only supports Graphite
+	GraphiteTagSupport bool
+
+	// JSONTimestampUnits is the precision of JSON timestamps
+	JSONTimestampUnits time.Duration
+
+	// JSONArray serializes batches as a JSON array
+	JSONArray bool
+
+	// JSONNesting is the JSON style of fields and tags, "nested" or "flat"
+	JSONNesting string
+
+	// PrometheusExportTimestamp adds timestamps to the Prometheus samples
+	PrometheusExportTimestamp bool
+
+	// PrometheusOpenMetrics writes the OpenMetrics text format
+	PrometheusOpenMetrics bool
+
+	// Carbon2Format is the naming of the Carbon2 samples, "field_separate"
+	// or "metric_includes_field"
+	Carbon2Format string
+
+	// WavefrontSourceOverride are the tags to use as Wavefront source
+	WavefrontSourceOverride []string
+
+	// WavefrontUseStrict allows slashes and commas in Wavefront metric names
+	WavefrontUseStrict bool
+
+	// WavefrontConvertPaths replaces underscores with dots in Wavefront
+	// metric names
+	WavefrontConvertPaths bool
 }
 
 // NewSerializer a Serializer interface based on the given config.
@@ -44,24 +124,92 @@ func NewSerializer(config *Config) (Serializer, error) {
 	case "influx":
 		serializer, err = NewInfluxSerializer()
 	case "graphite":
-		serializer, err = NewGraphiteSerializer(config.Prefix, config.Template)
+		serializer, err = NewGraphiteSerializer(config.Prefix, config.Template,
+			config.GraphiteTagSupport)
 	case "json":
-		serializer, err = NewJsonSerializer()
+		serializer, err = NewJsonSerializer(config.JSONTimestampUnits,
+			config.JSONArray, config.JSONNesting)
+	case "prometheus":
+		serializer, err = NewPrometheusSerializer(
+			config.PrometheusExportTimestamp, config.PrometheusOpenMetrics)
+	case "carbon2":
+		serializer, err = NewCarbon2Serializer(config.Carbon2Format)
+	case "wavefront":
+		serializer, err = NewWavefrontSerializer(config.Prefix,
+			config.WavefrontUseStrict, config.WavefrontSourceOverride,
+			config.WavefrontConvertPaths)
+	case "msgpack":
+		serializer, err = NewMsgpackSerializer()
+	case "protobuf":
+		serializer, err = NewProtobufSerializer()
 	}
 	return serializer, err
 }
 
-func NewJsonSerializer() (Serializer, error) {
-	return &json.JsonSerializer{}, nil
+func NewJsonSerializer(
+	timestampUnits time.Duration,
+	array bool,
+	nesting string,
+) (Serializer, error) {
+	serializer, err := json.NewJsonSerializer(timestampUnits, array, nesting)
+	if err != nil {
+		return nil, err
+	}
+	return serializer, nil
+}
+
+func NewPrometheusSerializer(
+	exportTimestamp bool,
+	openMetrics bool,
+) (Serializer, error) {
+	return &prometheus.PrometheusSerializer{
+		ExportTimestamp: exportTimestamp,
+		OpenMetrics:     openMetrics,
+	}, nil
+}
+
+func NewCarbon2Serializer(format string) (Serializer, error) {
+	serializer, err := carbon2.NewCarbon2Serializer(format)
+	if err != nil {
+		return nil, err
+	}
+	return serializer, nil
+}
+
+func NewWavefrontSerializer(
+	prefix string,
+	useStrict bool,
+	sourceOverride []string,
+	convertPaths bool,
+) (Serializer, error) {
+	return &wavefront.WavefrontSerializer{
+		Prefix:         prefix,
+		UseStrict:      useStrict,
+		SourceOverride: sourceOverride,
+		ConvertPaths:   convertPaths,
+	}, nil
+}
+
+func NewMsgpackSerializer() (Serializer, error) {
+	return &msgpack.MsgpackSerializer{}, nil
+}
+
+func NewProtobufSerializer() (Serializer, error) {
+	return &protobuf.ProtobufSerializer{}, nil
 }
 
 func NewInfluxSerializer() (Serializer, error) {
 	return &influx.InfluxSerializer{}, nil
 }
 
-func NewGraphiteSerializer(prefix, template string) (Serializer, error) {
+func NewGraphiteSerializer(
+	prefix string,
+	template string,
+	tagSupport bool,
+) (Serializer, error) {
 	return &graphite.GraphiteSerializer{
-		Prefix:   prefix,
-		Template: template,
+		Prefix:     prefix,
+		Template:   template,
+		TagSupport: tagSupport,
 	}, nil
 }
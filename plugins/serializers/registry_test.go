@@ -0,0 +1,30 @@
+package serializers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/serializers/influx"
+	"github.com/influxdata/telegraf/plugins/serializers/json"
+)
+
+func TestNewBatchSerializer(t *testing.T) {
+	m, err := telegraf.NewMetric("cpu",
+		map[string]string{"cpu": "cpu0"},
+		map[string]interface{}{"usage_idle": float64(91.5)},
+		time.Unix(0, 0))
+	require.NoError(t, err)
+
+	s := NewBatchSerializer(&influx.InfluxSerializer{})
+	buf, err := s.SerializeBatch([]telegraf.Metric{m, m})
+	require.NoError(t, err)
+	line := "cpu,cpu=cpu0 usage_idle=91.5 0\n"
+	assert.Equal(t, line+line, string(buf))
+
+	js := &json.JsonSerializer{Array: true}
+	assert.Equal(t, js, NewBatchSerializer(js))
+}
@@ -0,0 +1,150 @@
+package wavefront
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+)
+
+// maxTagLength is the maximum length of a point tag key and value together.
+const maxTagLength = 254
+
+var (
+	// metricNameSanitize and strictMetricNameSanitize replace the
+	// characters not allowed in metric names. The strict mode of the
+	// Wavefront proxy also allows slashes and commas.
+	metricNameSanitize       = regexp.MustCompile(`[^a-zA-Z0-9_.\-]`)
+	strictMetricNameSanitize = regexp.MustCompile(`[^a-zA-Z0-9_.\-/,]`)
+	tagKeySanitize           = regexp.MustCompile(`[^a-zA-Z0-9_.\-]`)
+
+	tagValueEscaper = strings.NewReplacer(`"`, `\"`, "\n", `\n`)
+)
+
+// WavefrontSerializer serializes metrics into the Wavefront data format, ie
+// `"cpu.usage_idle" 91.5 1479822000 source="web01" "cpu"="cpu0"`. The
+// metric name is made of the prefix, the measurement and the field, and the
+// tags become point tags. String fields are skipped and booleans written as
+// 1 or 0.
+type WavefrontSerializer struct {
+	Prefix string
+	// UseStrict allows slashes and commas in metric names.
+	UseStrict bool
+	// SourceOverride are the tags used as source, in order of preference,
+	// before the "source" and "host" tags.
+	SourceOverride []string
+	// ConvertPaths replaces the underscores of metric names with dots.
+	ConvertPaths bool
+}
+
+func (s *WavefrontSerializer) Serialize(metric telegraf.Metric) ([]string, error) {
+	out := []string{}
+
+	source, tags := s.source(metric.Tags())
+	pointTags := serializeTags(tags)
+	timestamp := metric.UnixNano() / 1000000000
+
+	for fieldName, value := range metric.Fields() {
+		valueS, ok := formatValue(value)
+		if !ok {
+			continue
+		}
+		out = append(out, fmt.Sprintf("\"%s\" %s %d source=\"%s\"%s",
+			s.metricName(metric.Name(), fieldName),
+			valueS,
+			timestamp,
+			tagValueEscaper.Replace(source),
+			pointTags))
+	}
+	return out, nil
+}
+
+// metricName returns the sanitized name of a field. Fields named "value" are
+// named after the measurement only.
+func (s *WavefrontSerializer) metricName(measurement, field string) string {
+	name := s.Prefix + measurement
+	if field != "value" {
+		name += "." + field
+	}
+	if s.ConvertPaths {
+		name = strings.Replace(name, "_", ".", -1)
+	}
+	if s.UseStrict {
+		return strictMetricNameSanitize.ReplaceAllString(name, "-")
+	}
+	return metricNameSanitize.ReplaceAllString(name, "-")
+}
+
+// source returns the source of a point and its remaining tags. The first
+// tag of SourceOverride found is used as source, and a host tag is then
+// kept as "telegraf_host". Otherwise, the "source" or "host" tag is used.
+func (s *WavefrontSerializer) source(metricTags map[string]string) (string, map[string]string) {
+	tags := make(map[string]string, len(metricTags))
+	for k, v := range metricTags {
+		tags[k] = v
+	}
+
+	for _, k := range s.SourceOverride {
+		if v, ok := tags[k]; ok && v != "" {
+			delete(tags, k)
+			if host, ok := tags["host"]; ok {
+				delete(tags, "host")
+				tags["telegraf_host"] = host
+			}
+			return v, tags
+		}
+	}
+	for _, k := range []string{"source", "host"} {
+		if v, ok := tags[k]; ok && v != "" {
+			delete(tags, k)
+			return v, tags
+		}
+	}
+	return "telegraf", tags
+}
+
+// serializeTags returns the point tags sorted by key, each preceded by a
+// space. Values are truncated to fit the maximum tag length, and tags with
+// an empty key or value are skipped.
+func serializeTags(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for k, v := range tags {
+		if k == "" || v == "" {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var s string
+	for _, k := range keys {
+		key := tagKeySanitize.ReplaceAllString(k, "-")
+		value := tags[k]
+		if len(key)+len(value) > maxTagLength {
+			if len(key) >= maxTagLength {
+				continue
+			}
+			value = value[:maxTagLength-len(key)]
+		}
+		s += fmt.Sprintf(" \"%s\"=\"%s\"", key, tagValueEscaper.Replace(value))
+	}
+	return s
+}
+
+func formatValue(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case bool:
+		if v {
+			return "1", true
+		}
+		return "0", true
+	}
+	return "", false
+}
@@ -0,0 +1,81 @@
+package wavefront
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+)
+
+var testTime = time.Unix(1479822000, 0)
+
+func TestSerialize(t *testing.T) {
+	m, err := telegraf.NewMetric("cpu",
+		map[string]string{"cpu": "cpu0", "host": "web01", "dc name": `eu "1"`},
+		map[string]interface{}{"usage_idle": float64(91.5), "state": "ok"},
+		testTime)
+	require.NoError(t, err)
+
+	s := WavefrontSerializer{Prefix: "telegraf."}
+	lines, err := s.Serialize(m)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		`"telegraf.cpu.usage_idle" 91.5 1479822000 source="web01" "cpu"="cpu0" "dc-name"="eu \"1\""`,
+	}, lines)
+}
+
+func TestSerializeMetricName(t *testing.T) {
+	m, err := telegraf.NewMetric("disk_io/sda",
+		map[string]string{},
+		map[string]interface{}{"value": int64(1)},
+		testTime)
+	require.NoError(t, err)
+
+	tests := []struct {
+		serializer WavefrontSerializer
+		expected   string
+	}{
+		{WavefrontSerializer{}, `"disk_io-sda" 1 1479822000 source="telegraf"`},
+		{WavefrontSerializer{UseStrict: true}, `"disk_io/sda" 1 1479822000 source="telegraf"`},
+		{WavefrontSerializer{ConvertPaths: true}, `"disk.io-sda" 1 1479822000 source="telegraf"`},
+	}
+	for _, tt := range tests {
+		lines, err := tt.serializer.Serialize(m)
+		require.NoError(t, err)
+		assert.Equal(t, []string{tt.expected}, lines)
+	}
+}
+
+func TestSerializeSourceOverride(t *testing.T) {
+	m, err := telegraf.NewMetric("docker",
+		map[string]string{"host": "web01", "container": "nginx"},
+		map[string]interface{}{"running": true},
+		testTime)
+	require.NoError(t, err)
+
+	s := WavefrontSerializer{SourceOverride: []string{"hostname", "container"}}
+	lines, err := s.Serialize(m)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		`"docker.running" 1 1479822000 source="nginx" "telegraf_host"="web01"`,
+	}, lines)
+}
+
+func TestSerializeLongTag(t *testing.T) {
+	m, err := telegraf.NewMetric("app",
+		map[string]string{"query": strings.Repeat("x", 300)},
+		map[string]interface{}{"value": int64(1)},
+		testTime)
+	require.NoError(t, err)
+
+	s := WavefrontSerializer{}
+	lines, err := s.Serialize(m)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		`"app" 1 1479822000 source="telegraf" "query"="` + strings.Repeat("x", 249) + `"`,
+	}, lines)
+}